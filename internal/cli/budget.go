@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"privatebox/internal/config"
+	"privatebox/internal/orchestration"
+	"privatebox/internal/providers"
+	"privatebox/internal/providers/aws"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// runningInstance is an instance of a profile that is currently running.
+type runningInstance struct {
+	Name string
+	Info *providers.RuntimeInfo
+}
+
+// profileInstance is an instance that was created with a profile.
+type profileInstance struct {
+	Name    string
+	Outputs auto.OutputMap
+	Info    *providers.RuntimeInfo // nil if Err is set
+	Err     error                  // Why the status could not be fetched
+}
+
+// listProfileInstances returns the instances that were created with profileName,
+// sorted by name, with their live status.
+func listProfileInstances(ctx context.Context, profile *config.Profile, profileName string) ([]profileInstance, error) {
+	if profile.Provider != providerAWS {
+		return nil, fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+
+	stacks, err := orchestration.ListStacks(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	var (
+		mu        sync.Mutex
+		instances []profileInstance
+		wg        sync.WaitGroup
+	)
+
+	for _, stackName := range stacks {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			provider := aws.New(*profile)
+			mgr := orchestration.NewStackManager(profile, provider, name)
+			outs, err := mgr.GetOutputs(ctx)
+			if err != nil {
+				return
+			}
+
+			if owner, _ := outs["profileName"].Value.(string); owner != profileName {
+				return
+			}
+			id, ok := outs["instanceID"].Value.(string)
+			if !ok || id == "" {
+				return
+			}
+
+			inst := profileInstance{Name: name, Outputs: outs}
+			inst.Info, inst.Err = provider.GetInstanceStatus(ctx, id)
+
+			mu.Lock()
+			instances = append(instances, inst)
+			mu.Unlock()
+		}(stackName)
+	}
+
+	wg.Wait()
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+	return instances, nil
+}
+
+// listRunningInstances returns the running instances that were created with profileName.
+func listRunningInstances(ctx context.Context, profile *config.Profile, profileName string) ([]runningInstance, error) {
+	instances, err := listProfileInstances(ctx, profile, profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var running []runningInstance
+	for _, inst := range instances {
+		if inst.Err != nil || (inst.Info.State != "running" && inst.Info.State != "pending") {
+			continue
+		}
+		running = append(running, runningInstance{Name: inst.Name, Info: inst.Info})
+	}
+	return running, nil
+}
+
+// hourlyPrice returns the compute price of a running instance, or of instanceType if
+// info is nil, and whether it is known.
+type hourlyPrice func(instanceType string, info *providers.RuntimeInfo) (float64, bool)
+
+// checkBudget verifies that starting (or creating) instanceName with instanceType keeps
+// the profile within its budget.
+func checkBudget(ctx context.Context, profile *config.Profile, profileName, instanceName, instanceType string) error {
+	budget := profile.Budget
+	if budget == nil {
+		return nil
+	}
+	if err := checkFamily(budget, profileName, instanceType); err != nil {
+		return err
+	}
+	if budget.MaxRunning <= 0 && budget.MaxHourly <= 0 {
+		return nil
+	}
+
+	running, err := listRunningInstances(ctx, profile, profileName)
+	if err != nil {
+		return fmt.Errorf("failed to check budget: %w", err)
+	}
+
+	var price hourlyPrice
+	if budget.MaxHourly > 0 {
+		table, err := loadPriceTable()
+		if err != nil {
+			return err
+		}
+		provider := aws.New(*profile)
+		price = func(instanceType string, info *providers.RuntimeInfo) (float64, bool) {
+			if info == nil {
+				return table.InstanceHourly(profile.Region, instanceType)
+			}
+			return instanceHourly(ctx, table, provider, profile.Region, info)
+		}
+	}
+	return checkLimits(budget, profileName, instanceName, instanceType, running, price)
+}
+
+// checkFamily verifies that instanceType belongs to one of the budget's allowed families.
+func checkFamily(budget *config.Budget, profileName, instanceType string) error {
+	if len(budget.AllowedFamilies) == 0 {
+		return nil
+	}
+	family, _, _ := strings.Cut(instanceType, ".")
+	if !familyAllowed(family, budget.AllowedFamilies) {
+		return budgetError(profileName, "instance type %s is not in the allowed families (%s)",
+			instanceType, strings.Join(budget.AllowedFamilies, ", "))
+	}
+	return nil
+}
+
+// checkLimits verifies max_running and max_hourly, given the profile's running
+// instances (which may include instanceName itself). price is only used for max_hourly.
+func checkLimits(budget *config.Budget, profileName, instanceName, instanceType string, running []runningInstance, price hourlyPrice) error {
+	var others []runningInstance
+	for _, r := range running {
+		if r.Name != instanceName {
+			others = append(others, r)
+		}
+	}
+
+	if budget.MaxRunning > 0 && len(others)+1 > budget.MaxRunning {
+		return budgetError(profileName, "%d instance(s) already running, the limit is %d", len(others), budget.MaxRunning)
+	}
+
+	if budget.MaxHourly > 0 {
+		hourly, ok := price(instanceType, nil)
+		if !ok {
+			return budgetError(profileName, "no price known for %s, cannot check max_hourly (run 'privatebox cost refresh')", instanceType)
+		}
+
+		current := 0.0
+		for _, r := range others {
+			if p, ok := price(r.Info.InstanceType, r.Info); ok {
+				current += p
+			}
+		}
+		if current+hourly > budget.MaxHourly {
+			return budgetError(profileName, "running instances cost $%.4f/h, adding %s ($%.4f/h) exceeds max_hourly $%.2f/h",
+				current, instanceType, hourly, budget.MaxHourly)
+		}
+	}
+	return nil
+}
+
+func familyAllowed(family string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(pattern, family); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func budgetError(profileName, format string, args ...any) error {
+	return fmt.Errorf("budget of profile '%s' exceeded: %s (use --force to override)", profileName, fmt.Sprintf(format, args...))
+}
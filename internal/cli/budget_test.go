@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"strings"
+	"testing"
+)
+
+func TestFamilyAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  string
+		allowed []string
+		want    bool
+	}{
+		{name: "exact", family: "t3", allowed: []string{"t3", "t4g"}, want: true},
+		{name: "not listed", family: "m7i", allowed: []string{"t3", "t4g"}, want: false},
+		{name: "prefix is not a match", family: "t3a", allowed: []string{"t3"}, want: false},
+		{name: "glob", family: "m7i", allowed: []string{"t3", "m7*"}, want: true},
+		{name: "glob does not match other generations", family: "m6i", allowed: []string{"m7*"}, want: false},
+		{name: "character class", family: "c7g", allowed: []string{"c7[gi]"}, want: true},
+		{name: "nothing allowed", family: "t3", allowed: nil, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := familyAllowed(tc.family, tc.allowed); got != tc.want {
+				t.Errorf("familyAllowed(%q, %v) = %v, want %v", tc.family, tc.allowed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckFamily(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowed      []string
+		instanceType string
+		wantErr      bool
+	}{
+		{name: "no restriction", instanceType: "p5.48xlarge"},
+		{name: "allowed", allowed: []string{"t3"}, instanceType: "t3.micro"},
+		{name: "allowed by glob", allowed: []string{"m7*"}, instanceType: "m7g.large"},
+		{name: "rejected", allowed: []string{"t3", "t4g"}, instanceType: "c5.large", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkFamily(&config.Budget{AllowedFamilies: tc.allowed}, "dev", tc.instanceType)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkFamily() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	prices := map[string]float64{"t3.micro": 0.0104, "t3.large": 0.0832, "m7i.xlarge": 0.2016}
+	price := func(instanceType string, _ *providers.RuntimeInfo) (float64, bool) {
+		p, ok := prices[instanceType]
+		return p, ok
+	}
+	running := func(names ...string) []runningInstance {
+		var r []runningInstance
+		for _, n := range names {
+			name, instanceType, _ := strings.Cut(n, ":")
+			r = append(r, runningInstance{Name: name, Info: &providers.RuntimeInfo{InstanceType: instanceType, State: "running"}})
+		}
+		return r
+	}
+
+	tests := []struct {
+		name         string
+		budget       config.Budget
+		instance     string
+		instanceType string
+		running      []runningInstance
+		wantErr      string
+	}{
+		{
+			name:         "no limits",
+			instance:     "new",
+			instanceType: "m7i.xlarge",
+			running:      running("a:m7i.xlarge", "b:m7i.xlarge"),
+		},
+		{
+			name:         "below max_running",
+			budget:       config.Budget{MaxRunning: 2},
+			instance:     "new",
+			instanceType: "t3.micro",
+			running:      running("a:t3.micro"),
+		},
+		{
+			name:         "at max_running",
+			budget:       config.Budget{MaxRunning: 2},
+			instance:     "new",
+			instanceType: "t3.micro",
+			running:      running("a:t3.micro", "b:t3.micro"),
+			wantErr:      "2 instance(s) already running, the limit is 2",
+		},
+		{
+			name:         "instance itself is not counted",
+			budget:       config.Budget{MaxRunning: 2},
+			instance:     "a",
+			instanceType: "t3.micro",
+			running:      running("a:t3.micro", "b:t3.micro"),
+		},
+		{
+			name:         "below max_hourly",
+			budget:       config.Budget{MaxHourly: 0.10},
+			instance:     "new",
+			instanceType: "t3.micro",
+			running:      running("a:t3.large"),
+		},
+		{
+			name:         "above max_hourly",
+			budget:       config.Budget{MaxHourly: 0.25},
+			instance:     "new",
+			instanceType: "m7i.xlarge",
+			running:      running("a:t3.large"),
+			wantErr:      "exceeds max_hourly $0.25/h",
+		},
+		{
+			name:         "resize replaces the instance's own price",
+			budget:       config.Budget{MaxHourly: 0.25},
+			instance:     "a",
+			instanceType: "m7i.xlarge",
+			running:      running("a:t3.large"),
+		},
+		{
+			name:         "unknown price of the new type",
+			budget:       config.Budget{MaxHourly: 1},
+			instance:     "new",
+			instanceType: "x2iedn.32xlarge",
+			wantErr:      "no price known for x2iedn.32xlarge",
+		},
+		{
+			name:         "unknown prices of running instances are skipped",
+			budget:       config.Budget{MaxHourly: 0.05},
+			instance:     "new",
+			instanceType: "t3.micro",
+			running:      running("a:x2iedn.32xlarge"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkLimits(&tc.budget, "dev", tc.instance, tc.instanceType, tc.running, price)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkLimits() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("checkLimits() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"privatebox/internal/userdata"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/urfave/cli/v3"
+)
+
+// connection holds everything needed to reach an instance.
+type connection struct {
+	Name       string
+	InstanceID string
+	User       string
+	IP         string
+	Hostname   string // DNS name of the instance (may be empty)
+	KeyPath    string // Private key path derived from the profile's public key (may be empty)
+	Mode       string // config.ConnectModeSSH or config.ConnectModeSSM
+	Profile    *config.Profile
+	Provider   providers.CloudProvider
+}
+
+// connectOptions controls how resolveConnection treats stopped instances.
+type connectOptions struct {
+	Start   bool          // Start a stopped instance without asking
+	Prompt  bool          // Ask before starting a stopped instance
+	Timeout time.Duration // How long to wait for the instance to become ready
+	Retries int           // How often to retry starting when no capacity is available
+	Force   bool          // Ignore the profile's budget when starting
+}
+
+// resolveConnection looks up the instance and its live public IP (unless it is
+// reached through SSM). Stack outputs are not used for the IP because it changes
+// on every stop/start.
+func resolveConnection(ctx context.Context, cmd *cli.Command, name string, opts connectOptions) (*connection, error) {
+	mgr, cfg, profileName, provider, err := getStackManager(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceID, ok := outs["instanceID"].Value.(string)
+	if !ok || instanceID == "" {
+		return nil, fmt.Errorf("instance ID not found in stack outputs, instance might not be ready")
+	}
+
+	mode := outputConnectMode(outs)
+
+	info, err := provider.GetInstanceStatus(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance status: %w", err)
+	}
+
+	switch info.State {
+	case "running":
+	case "pending":
+		fmt.Printf("Instance '%s' is starting...\n", name)
+		if info, err = waitForReady(ctx, provider, instanceID, mode, opts.Timeout); err != nil {
+			return nil, err
+		}
+	case "stopped", "stopping":
+		if !opts.Start && !(opts.Prompt && confirm(fmt.Sprintf("Instance '%s' is %s. Start it", name, info.State))) {
+			return nil, fmt.Errorf("instance '%s' is %s (use --up to start it)", name, info.State)
+		}
+		if info.State == "stopping" {
+			if _, err := waitForState(ctx, provider, instanceID, "stopped", opts.Timeout); err != nil {
+				return nil, err
+			}
+		}
+		if !opts.Force {
+			if err := checkBudget(ctx, cfg, profileName, name, info.InstanceType); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Printf("Starting instance '%s' (%s)...\n", name, instanceID)
+		if err := startInstance(ctx, provider, instanceID, opts.Retries); err != nil {
+			return nil, err
+		}
+		if info, err = waitForReady(ctx, provider, instanceID, mode, opts.Timeout); err != nil {
+			return nil, err
+		}
+		updateDNSRecord(ctx, provider, outs, info)
+	default:
+		return nil, fmt.Errorf("instance '%s' is %s", name, info.State)
+	}
+
+	conn := newConnection(name, instanceID, outs, info, cfg, provider)
+	if !conn.Reachable() {
+		return nil, fmt.Errorf("instance '%s' has no public IP (set connect_mode: ssm to reach private instances)", name)
+	}
+	return conn, nil
+}
+
+// outputConnectMode returns the connect mode an instance was created with.
+// Instances created by older versions have none and use SSH.
+func outputConnectMode(outs auto.OutputMap) string {
+	if mode, _ := outs["connectMode"].Value.(string); mode == config.ConnectModeSSM {
+		return mode
+	}
+	return config.ConnectModeSSH
+}
+
+// outputSSHUser returns the login user recorded when the instance was created.
+// Instances created by older versions have none and use the profile's ssh_user or
+// the provider's default.
+func outputSSHUser(outs auto.OutputMap, cfg *config.Profile, provider providers.CloudProvider) string {
+	if user, _ := outs["sshUser"].Value.(string); user != "" {
+		return user
+	}
+	if cfg.SSHUser != "" {
+		return cfg.SSHUser
+	}
+	return provider.GetSSHUser()
+}
+
+// newConnection builds a connection for an instance whose stack outputs and runtime
+// info are already known.
+func newConnection(name, instanceID string, outs auto.OutputMap, info *providers.RuntimeInfo, cfg *config.Profile, provider providers.CloudProvider) *connection {
+	// Determine Private Key Path
+	privKeyPath := ""
+	if cfg.SSHPublicKey != "" {
+		privKeyPath = strings.TrimSuffix(cfg.SSHPublicKey, ".pub")
+	}
+
+	ip := info.PublicIP
+	if elasticIP, _ := outs["elasticIP"].Value.(string); elasticIP != "" {
+		ip = elasticIP
+	}
+	hostname, _ := outs["hostname"].Value.(string)
+
+	return &connection{
+		Name:       name,
+		InstanceID: instanceID,
+		User:       outputSSHUser(outs, cfg, provider),
+		IP:         ip,
+		Hostname:   hostname,
+		KeyPath:    privKeyPath,
+		Mode:       outputConnectMode(outs),
+		Profile:    cfg,
+		Provider:   provider,
+	}
+}
+
+// SSM reports whether SSH is tunneled through SSM Session Manager.
+func (c *connection) SSM() bool {
+	return c.Mode == config.ConnectModeSSM
+}
+
+// Reachable reports whether the instance can currently be connected to.
+func (c *connection) Reachable() bool {
+	return c.SSM() || c.IP != ""
+}
+
+// Address returns what ssh connects to: the public IP, or the instance ID when
+// the connection is tunneled through SSM.
+func (c *connection) Address() string {
+	if c.SSM() {
+		return c.InstanceID
+	}
+	return c.IP
+}
+
+// Host returns the user@address destination.
+func (c *connection) Host() string {
+	return fmt.Sprintf("%s@%s", c.User, c.Address())
+}
+
+// ProxyCommand returns the ssh ProxyCommand that tunnels through SSM, or "" when
+// the instance is reached directly.
+func (c *connection) ProxyCommand() string {
+	if !c.SSM() {
+		return ""
+	}
+	proxy := "aws ssm start-session --target %h --document-name AWS-StartSSHSession --parameters portNumber=%p"
+	if region := c.Profile.Region; region != "" {
+		proxy += " --region " + region
+	}
+	if profile := c.Profile.AWS.Profile; profile != "" {
+		proxy += " --profile " + profile
+	}
+	return proxy
+}
+
+// Expand replaces the {user}, {ip}, {id}, {key}, {host} and {proxy} placeholders in a
+// command template. {host} is user@hostname for instances with a DNS name, otherwise
+// the same as {user}@{ip}. For instances reached through SSM, {ip} is the instance ID
+// and {proxy} the ssh option that tunnels through SSM; it is added to ssh commands
+// that lack it, so the usual "ssh -i {key} {user}@{ip}" templates keep working.
+func (c *connection) Expand(template string) string {
+	s := template
+	if c.SSM() && !strings.Contains(s, "{proxy}") && strings.HasPrefix(s, "ssh ") {
+		s = "ssh {proxy} " + strings.TrimPrefix(s, "ssh ")
+	}
+
+	proxy := ""
+	if c.SSM() {
+		proxy = "-o " + userdata.ShellQuote("ProxyCommand="+c.ProxyCommand())
+	}
+
+	host := c.Host()
+	if c.Hostname != "" && !c.SSM() {
+		host = c.User + "@" + c.Hostname
+	}
+
+	s = strings.ReplaceAll(s, "{user}", c.User)
+	s = strings.ReplaceAll(s, "{ip}", c.Address())
+	s = strings.ReplaceAll(s, "{id}", c.InstanceID)
+	s = strings.ReplaceAll(s, "{key}", c.KeyPath)
+	s = strings.ReplaceAll(s, "{host}", host)
+	s = strings.ReplaceAll(s, "{proxy}", proxy)
+	return s
+}
+
+// SSHOptions returns the ssh options (identity file, SSM proxy) needed to reach the
+// instance. They are also understood by scp.
+func (c *connection) SSHOptions() []string {
+	var args []string
+	if c.KeyPath != "" {
+		args = append(args, "-i", expandHome(c.KeyPath))
+	}
+	if proxy := c.ProxyCommand(); proxy != "" {
+		args = append(args, "-o", "ProxyCommand="+proxy)
+	}
+	return args
+}
+
+// SSHArgs returns the ssh options followed by opts and the destination.
+func (c *connection) SSHArgs(opts ...string) []string {
+	args := append(c.SSHOptions(), opts...)
+	return append(args, c.Host())
+}
+
+// Env returns the environment for commands run against the instance.
+func (c *connection) Env() []string {
+	env := os.Environ()
+
+	// 1. AWS Profile from config
+	if c.Profile.AWS.Profile != "" {
+		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", c.Profile.AWS.Profile))
+	}
+
+	// 2. Custom environment variables from profile
+	for k, v := range c.Profile.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
+
+// expandHome expands a leading "~/" to the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		dirname, _ := os.UserHomeDir()
+		return filepath.Join(dirname, path[2:])
+	}
+	return path
+}
+
+// confirm asks a yes/no question and reports whether the user agreed.
+func confirm(label string) bool {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+	_, err := prompt.Run()
+	return err == nil
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"privatebox/internal/config"
+	"privatebox/internal/orchestration"
+	"privatebox/internal/pricing"
+	"privatebox/internal/providers"
+	"privatebox/internal/providers/aws"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/urfave/cli/v3"
+)
+
+// costEstimate is the estimated cost of a single instance.
+type costEstimate struct {
+	Hourly       float64 // Compute and public IPv4 cost while running
+	MonthlyFixed float64 // Storage, KMS and elastic IP, charged whether running or not
+	MonthToDate  float64 // Fixed cost this month plus the hours run this month
+	Known        bool    // False if the instance type is missing from the price table
+	UptimeKnown  bool    // False if the hours run are unknown; MonthToDate then only has fixed cost
+}
+
+// Monthly returns the cost of running the instance around the clock for a month.
+func (e costEstimate) Monthly() float64 {
+	return e.Hourly*pricing.HoursPerMonth + e.MonthlyFixed
+}
+
+// costInputs is what the cost of an instance is estimated from.
+type costInputs struct {
+	Hourly       float64 // Compute price (live spot price for spot instances)
+	Known        bool    // False if the instance type is missing from the price table
+	Volumes      []providers.VolumeInfo
+	PublicIP     bool    // Gets a public IPv4 address while running
+	ElasticIP    bool    // Has an elastic IP, charged whether running or not
+	RunningHours float64 // Hours run this month, negative if unknown
+}
+
+func loadPriceTable() (*pricing.Table, error) {
+	loader, err := config.NewLoader()
+	if err != nil {
+		return nil, err
+	}
+	return pricing.Load(loader.GetPricesPath())
+}
+
+// instanceHourly returns the compute price of a running instance, using the live
+// spot price for spot instances.
+func instanceHourly(ctx context.Context, table *pricing.Table, provider providers.CloudProvider, region string, info *providers.RuntimeInfo) (float64, bool) {
+	if info.Lifecycle == "spot" {
+		if price, err := provider.GetSpotPrice(ctx, info.InstanceType, info.AvailabilityZone); err == nil {
+			return price, true
+		}
+	}
+	return table.InstanceHourly(region, info.InstanceType)
+}
+
+// monthStart returns the start of the billing month (UTC) that now is in.
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// estimateCost estimates hourly, monthly and month-to-date cost of an instance.
+// Month-to-date compute prices the hours run at the current type and price.
+func estimateCost(table *pricing.Table, region string, in costInputs, now time.Time) costEstimate {
+	est := costEstimate{Hourly: in.Hourly, Known: in.Known, UptimeKnown: in.RunningHours >= 0}
+
+	ipv4 := table.PublicIPv4Hourly(region)
+	if in.ElasticIP {
+		est.MonthlyFixed += ipv4 * pricing.HoursPerMonth
+	} else if in.PublicIP {
+		est.Hourly += ipv4
+	}
+
+	// One KMS key per instance
+	est.MonthlyFixed += table.KMSKeyMonthly(region)
+
+	createdAt := now
+	for _, v := range in.Volumes {
+		if price, ok := table.StorageMonthly(region, v.Type, v.SizeGiB); ok {
+			est.MonthlyFixed += price
+		}
+		if !v.CreatedAt.IsZero() && v.CreatedAt.Before(createdAt) {
+			createdAt = v.CreatedAt
+		}
+	}
+
+	fixedSince := latest(monthStart(now), createdAt)
+	est.MonthToDate = est.MonthlyFixed * now.Sub(fixedSince).Hours() / pricing.HoursPerMonth
+	if est.UptimeKnown {
+		est.MonthToDate += est.Hourly * in.RunningHours
+	}
+	return est
+}
+
+// publicAddress reports whether the instance has an elastic IP or, if not, gets a
+// public IPv4 address while running.
+func publicAddress(outs auto.OutputMap) (elastic, public bool) {
+	if ip, _ := outs["elasticIP"].Value.(string); ip != "" {
+		return true, false
+	}
+	raw, _ := outs[providers.SpecOutput].Value.(string)
+	spec, err := providers.ParseSpec(raw)
+	if err != nil || spec.Network == nil {
+		// Created in the default VPC, whose subnets assign public IPs
+		return false, true
+	}
+	return false, spec.Network.AssociatePublicIP
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func formatPrice(price float64, known bool) string {
+	if !known {
+		return "?"
+	}
+	return fmt.Sprintf("$%.4f", price)
+}
+
+func formatDollars(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+type profileCost struct {
+	Running int
+	Hourly  float64
+	Monthly float64
+	MTD     float64
+}
+
+func showCost(ctx context.Context, cmd *cli.Command) error {
+	profile, _, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+
+	table, err := loadPriceTable()
+	if err != nil {
+		return err
+	}
+
+	var instances []string
+	if name := cmd.Args().First(); name != "" {
+		instances = []string{name}
+	} else {
+		instances, err = orchestration.ListStacks(profile)
+		if err != nil {
+			return fmt.Errorf("failed to list instances: %w", err)
+		}
+	}
+
+	if len(instances) == 0 {
+		fmt.Println("No instances found.")
+		return nil
+	}
+
+	out := tablewriter.NewWriter(os.Stdout)
+	out.SetHeader([]string{"NAME", "PROFILE", "TYPE", "STATE", "HOURLY", "MONTHLY (24x7)", "MTD (EST.)"})
+	out.SetBorder(false)
+	out.SetAutoWrapText(false)
+
+	now := time.Now()
+	totals := map[string]*profileCost{}
+
+	for _, instName := range instances {
+		var provider providers.CloudProvider
+		if profile.Provider == providerAWS {
+			provider = aws.New(*profile)
+		} else {
+			fmt.Fprintf(os.Stderr, "Skipping %s: unsupported provider %s\n", instName, profile.Provider)
+			continue
+		}
+
+		mgr := orchestration.NewStackManager(profile, provider, instName)
+		outs, err := mgr.GetOutputs(ctx)
+		if err != nil {
+			out.Append([]string{instName, "", "", "Error: " + err.Error(), "", "", ""})
+			continue
+		}
+
+		id, _ := outs["instanceID"].Value.(string)
+		profileName, _ := outs["profileName"].Value.(string)
+		if profileName == "" {
+			profileName = "Unknown"
+		}
+		if id == "" {
+			out.Append([]string{instName, profileName, "", "Provisioning/Error", "", "", ""})
+			continue
+		}
+
+		info, err := provider.GetInstanceStatus(ctx, id)
+		if err != nil {
+			out.Append([]string{instName, profileName, "", fmt.Sprintf("Error: %v", err), "", "", ""})
+			continue
+		}
+		volumes, err := provider.GetVolumes(ctx, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not list volumes of %s: %v\n", instName, err)
+		}
+
+		in := costInputs{Volumes: volumes}
+		in.Hourly, in.Known = instanceHourly(ctx, table, provider, profile.Region, info)
+		in.ElasticIP, in.PublicIP = publicAddress(outs)
+		if in.RunningHours, err = provider.RunningHours(ctx, id, monthStart(now)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the uptime of %s: %v\n", instName, err)
+			in.RunningHours = -1
+		}
+
+		est := estimateCost(table, profile.Region, in, now)
+		mtd := formatDollars(est.MonthToDate)
+		if !est.UptimeKnown {
+			mtd += " (storage only)"
+		}
+		out.Append([]string{
+			instName, profileName, info.InstanceType, info.State,
+			formatPrice(est.Hourly, est.Known) + "/h",
+			formatDollars(est.Monthly()),
+			mtd,
+		})
+
+		total, ok := totals[profileName]
+		if !ok {
+			total = &profileCost{}
+			totals[profileName] = total
+		}
+		if info.State == "running" {
+			total.Running++
+			total.Hourly += est.Hourly
+		}
+		total.Monthly += est.Monthly()
+		total.MTD += est.MonthToDate
+	}
+	out.Render()
+
+	fmt.Println()
+	summary := tablewriter.NewWriter(os.Stdout)
+	summary.SetHeader([]string{"PROFILE", "RUNNING", "HOURLY (RUNNING)", "MONTHLY (24x7)", "MTD (EST.)"})
+	summary.SetBorder(false)
+	summary.SetAutoWrapText(false)
+
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := totals[name]
+		summary.Append([]string{name, fmt.Sprint(t.Running), fmt.Sprintf("$%.4f/h", t.Hourly), formatDollars(t.Monthly), formatDollars(t.MTD)})
+	}
+	summary.Render()
+
+	fmt.Printf("\nPrices for %s updated %s. Month-to-date compute counts the hours each instance ran this month (from its CloudWatch metrics) at its current price.\n", profile.Region, table.UpdatedFor(profile.Region))
+	return nil
+}
+
+func refreshPrices(ctx context.Context, cmd *cli.Command) error {
+	profile, _, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+	if profile.Provider != providerAWS {
+		return fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+
+	loader, err := config.NewLoader()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fetching prices for %s...\n", profile.Region)
+	prices, err := aws.New(*profile).FetchPrices(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := pricing.SaveRegion(loader.GetPricesPath(), profile.Region, *prices); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d instance prices to %s\n", len(prices.Instances), loader.GetPricesPath())
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"math"
+	"privatebox/internal/pricing"
+	"privatebox/internal/providers"
+	"testing"
+	"time"
+)
+
+func TestEstimateCost(t *testing.T) {
+	table := &pricing.Table{
+		BaseRegion: "us-east-1",
+		Regions: map[string]pricing.RegionPrices{
+			"us-east-1": {
+				EBS:        map[string]float64{"gp3": 0.08},
+				KMSKey:     1,
+				PublicIPv4: 0.005,
+			},
+		},
+	}
+	// 10 days into a 30-day month
+	now := time.Date(2026, 9, 11, 0, 0, 0, 0, time.UTC)
+	fraction := 240.0 / pricing.HoursPerMonth
+	lastMonth := now.AddDate(0, -1, 0)
+	root := providers.VolumeInfo{Type: "gp3", SizeGiB: 100, CreatedAt: lastMonth} // $8/month
+
+	tests := []struct {
+		name        string
+		in          costInputs
+		wantHourly  float64
+		wantMonthly float64
+		wantMTD     float64
+		wantUptime  bool
+	}{
+		{
+			name:        "private instance",
+			in:          costInputs{Hourly: 0.1, Known: true, Volumes: []providers.VolumeInfo{root}, RunningHours: 50},
+			wantHourly:  0.1,
+			wantMonthly: 0.1*pricing.HoursPerMonth + 9,
+			wantMTD:     9*fraction + 0.1*50,
+			wantUptime:  true,
+		},
+		{
+			name:        "public IP is charged while running",
+			in:          costInputs{Hourly: 0.1, Known: true, Volumes: []providers.VolumeInfo{root}, PublicIP: true, RunningHours: 50},
+			wantHourly:  0.105,
+			wantMonthly: 0.105*pricing.HoursPerMonth + 9,
+			wantMTD:     9*fraction + 0.105*50,
+			wantUptime:  true,
+		},
+		{
+			name:        "elastic IP is charged while stopped",
+			in:          costInputs{Hourly: 0.1, Known: true, Volumes: []providers.VolumeInfo{root}, ElasticIP: true, PublicIP: true},
+			wantHourly:  0.1,
+			wantMonthly: 0.1*pricing.HoursPerMonth + 9 + 0.005*pricing.HoursPerMonth,
+			wantMTD:     (9 + 0.005*pricing.HoursPerMonth) * fraction,
+			wantUptime:  true,
+		},
+		{
+			name: "created this month",
+			in: costInputs{Hourly: 0.1, Known: true, RunningHours: 24, Volumes: []providers.VolumeInfo{
+				{Type: "gp3", SizeGiB: 100, CreatedAt: now.Add(-24 * time.Hour)},
+			}},
+			wantHourly:  0.1,
+			wantMonthly: 0.1*pricing.HoursPerMonth + 9,
+			wantMTD:     9.0*24/pricing.HoursPerMonth + 0.1*24,
+			wantUptime:  true,
+		},
+		{
+			name:        "unknown uptime only counts fixed cost",
+			in:          costInputs{Hourly: 0.1, Known: true, Volumes: []providers.VolumeInfo{root}, RunningHours: -1},
+			wantHourly:  0.1,
+			wantMonthly: 0.1*pricing.HoursPerMonth + 9,
+			wantMTD:     9 * fraction,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := estimateCost(table, "us-east-1", tc.in, now)
+			if math.Abs(got.Hourly-tc.wantHourly) > 1e-9 {
+				t.Errorf("Hourly = %v, want %v", got.Hourly, tc.wantHourly)
+			}
+			if math.Abs(got.Monthly()-tc.wantMonthly) > 1e-9 {
+				t.Errorf("Monthly() = %v, want %v", got.Monthly(), tc.wantMonthly)
+			}
+			if math.Abs(got.MonthToDate-tc.wantMTD) > 1e-9 {
+				t.Errorf("MonthToDate = %v, want %v", got.MonthToDate, tc.wantMTD)
+			}
+			if got.UptimeKnown != tc.wantUptime {
+				t.Errorf("UptimeKnown = %v, want %v", got.UptimeKnown, tc.wantUptime)
+			}
+		})
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	// Still August in UTC
+	got := monthStart(time.Date(2026, 9, 1, 1, 0, 0, 0, berlin))
+	if want := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("monthStart() = %v, want %v", got, want)
+	}
+}
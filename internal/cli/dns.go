@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"privatebox/internal/providers"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// outputDNSRecord returns the instance's DNS record if it has to follow the public
+// IP, or nil if the instance has none or the record points at an elastic IP.
+func outputDNSRecord(outs auto.OutputMap) *providers.DNSRecord {
+	raw, _ := outs[providers.SpecOutput].Value.(string)
+	spec, err := providers.ParseSpec(raw)
+	if err != nil || spec.ElasticIP {
+		return nil
+	}
+	return spec.DNS
+}
+
+// updateDNSRecord points the instance's DNS record at its public IP after a start.
+// Failures are only reported, the instance itself is fine.
+func updateDNSRecord(ctx context.Context, provider providers.CloudProvider, outs auto.OutputMap, info *providers.RuntimeInfo) {
+	record := outputDNSRecord(outs)
+	if record == nil || info.PublicIP == "" {
+		return
+	}
+	if err := provider.UpdateDNSRecord(ctx, *record, info.PublicIP); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	fmt.Printf("Pointed %s at %s.\n", record.Name, info.PublicIP)
+}
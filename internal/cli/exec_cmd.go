@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v3"
+)
+
+// instanceSelector matches instances by name, instance type or user data name
+// (globs allowed), or by tags.
+type instanceSelector map[string]string
+
+// parseSelector parses a comma separated list of KEY=VALUE terms, e.g.
+// "name=web-*,team=data". The keys name, type and userdata are special, all other
+// keys match the instance's tags.
+func parseSelector(s string) (instanceSelector, error) {
+	sel := instanceSelector{}
+	for _, term := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(term), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector term %q, expected KEY=VALUE", term)
+		}
+		sel[key] = value
+	}
+	return sel, nil
+}
+
+// Matches reports whether the instance matches all terms of the selector.
+func (sel instanceSelector) Matches(inst profileInstance) bool {
+	raw, _ := inst.Outputs[providers.SpecOutput].Value.(string)
+	spec, _ := providers.ParseSpec(raw)
+
+	for key, want := range sel {
+		var have string
+		switch key {
+		case "name":
+			have = inst.Name
+		case "type":
+			if inst.Info != nil {
+				have = inst.Info.InstanceType
+			}
+		case "userdata":
+			have, _ = inst.Outputs["userDataName"].Value.(string)
+		default:
+			have = spec.Tags[key]
+			if have == "" {
+				return false
+			}
+		}
+		if ok, _ := path.Match(want, have); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// execTargets returns the instances selected by the arguments and flags of exec,
+// and the command to run on them.
+func execTargets(ctx context.Context, cmd *cli.Command, profile *config.Profile, profileName string) ([]profileInstance, string, error) {
+	args := cmd.Args().Slice()
+	if !cmd.Bool("all") && cmd.String("selector") == "" {
+		if len(args) == 0 {
+			return nil, "", fmt.Errorf("instance name, --all or --selector is required")
+		}
+		name := args[0]
+		mgr, _, _, provider, err := getStackManager(cmd, name)
+		if err != nil {
+			return nil, "", err
+		}
+		outs, err := mgr.GetOutputs(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		id, _ := outs["instanceID"].Value.(string)
+		if id == "" {
+			return nil, "", fmt.Errorf("instance ID not found in stack outputs, instance might not be ready")
+		}
+		inst := profileInstance{Name: name, Outputs: outs}
+		inst.Info, inst.Err = provider.GetInstanceStatus(ctx, id)
+		return []profileInstance{inst}, strings.Join(args[1:], " "), nil
+	}
+
+	instances, err := listProfileInstances(ctx, profile, profileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if raw := cmd.String("selector"); raw != "" {
+		sel, err := parseSelector(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		var selected []profileInstance
+		for _, inst := range instances {
+			if sel.Matches(inst) {
+				selected = append(selected, inst)
+			}
+		}
+		instances = selected
+	}
+	return instances, strings.Join(args, " "), nil
+}
+
+// execResult is the outcome of exec on one instance.
+type execResult struct {
+	Name     string
+	ExitCode int
+	Skipped  string // Why the command was not run
+	Err      error
+	Duration time.Duration
+}
+
+func (r execResult) Failed() bool {
+	return r.Err != nil || r.ExitCode != 0
+}
+
+func (r execResult) String() string {
+	switch {
+	case r.Skipped != "":
+		return "skipped (" + r.Skipped + ")"
+	case r.Err != nil:
+		return "error: " + r.Err.Error()
+	default:
+		return fmt.Sprintf("exit %d", r.ExitCode)
+	}
+}
+
+func execCommand(ctx context.Context, cmd *cli.Command) error {
+	profile, profileName, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider(profile)
+	if err != nil {
+		return err
+	}
+	targets, command, err := execTargets(ctx, cmd, profile, profileName)
+	if err != nil {
+		return err
+	}
+	if command == "" {
+		return fmt.Errorf("command is required, e.g. privatebox exec my-vm -- uptime")
+	}
+	if len(targets) == 0 {
+		fmt.Println("No instances selected.")
+		return nil
+	}
+
+	parallel := max(cmd.Int("parallel"), 1)
+	results := make([]execResult, len(targets))
+	sem := make(chan struct{}, parallel)
+	var out sync.Mutex
+	var wg sync.WaitGroup
+	for i, inst := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			results[i] = execOn(ctx, profile, provider, inst, command, &out)
+			results[i].Name = inst.Name
+			results[i].Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	fmt.Println()
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"NAME", "RESULT", "DURATION"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	failed := 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+		table.Append([]string{r.Name, r.String(), r.Duration.Round(100 * time.Millisecond).String()})
+	}
+	table.Render()
+
+	if failed > 0 {
+		return fmt.Errorf("command failed on %d of %d instance(s)", failed, len(results))
+	}
+	return nil
+}
+
+// execOn runs the command on one instance: over SSH, or with SSM Run Command for
+// instances reached through SSM. Output lines are prefixed with the instance name.
+func execOn(ctx context.Context, profile *config.Profile, provider providers.CloudProvider, inst profileInstance, command string, out *sync.Mutex) execResult {
+	if inst.Err != nil {
+		return execResult{Err: fmt.Errorf("failed to get instance status: %w", inst.Err)}
+	}
+	if inst.Info.State != "running" {
+		return execResult{Skipped: inst.Info.State}
+	}
+
+	conn := newConnection(inst.Name, inst.Info.ID, inst.Outputs, inst.Info, profile, provider)
+
+	prefix := "[" + inst.Name + "] "
+	stdout := &prefixWriter{prefix: prefix, w: os.Stdout, mu: out}
+	stderr := &prefixWriter{prefix: prefix, w: os.Stderr, mu: out}
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	if conn.SSM() {
+		res, err := provider.RunCommand(ctx, conn.InstanceID, command)
+		if err != nil {
+			return execResult{Err: err}
+		}
+		_, _ = io.WriteString(stdout, res.Stdout)
+		_, _ = io.WriteString(stderr, res.Stderr)
+		return execResult{ExitCode: res.ExitCode}
+	}
+
+	if !conn.Reachable() {
+		return execResult{Err: fmt.Errorf("instance has no public IP")}
+	}
+	args := conn.SSHArgs("-o", "BatchMode=yes", "-o", "ConnectTimeout=10")
+	args = append(args, command)
+
+	//nolint:gosec // Arguments are derived from the instance's connection details and the user's command
+	c := exec.CommandContext(ctx, "ssh", args...)
+	c.Env = conn.Env()
+	c.Stdout = stdout
+	c.Stderr = stderr
+	err := c.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// ssh exits with 255 when it cannot connect
+		if exitErr.ExitCode() == 255 {
+			return execResult{Err: fmt.Errorf("ssh failed (exit 255)")}
+		}
+		return execResult{ExitCode: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return execResult{Err: err}
+	}
+	return execResult{}
+}
+
+// prefixWriter writes complete lines, each with a prefix. Writers sharing a mutex
+// don't interleave their lines.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(data), nil
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes a trailing incomplete line.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = io.WriteString(p.w, p.prefix)
+	_, _ = p.w.Write(line)
+}
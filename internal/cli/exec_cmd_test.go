@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"privatebox/internal/providers"
+	"reflect"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    instanceSelector
+		wantErr bool
+	}{
+		{in: "name=web-*", want: instanceSelector{"name": "web-*"}},
+		{in: "name=web-*, team=data", want: instanceSelector{"name": "web-*", "team": "data"}},
+		{in: "env=", want: instanceSelector{"env": ""}},
+		{in: "a=b=c", want: instanceSelector{"a": "b=c"}},
+		{in: "", wantErr: true},
+		{in: "web", wantErr: true},
+		{in: "=web", wantErr: true},
+		{in: "name=web,", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseSelector(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseSelector(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseSelector(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInstanceSelectorMatches(t *testing.T) {
+	spec := providers.InstanceSpec{Name: "web-1", Tags: map[string]string{"team": "data", "env": "prod"}}
+	inst := profileInstance{
+		Name: "web-1",
+		Outputs: auto.OutputMap{
+			providers.SpecOutput: {Value: spec.JSON()},
+			"userDataName":       {Value: "docker"},
+		},
+		Info: &providers.RuntimeInfo{InstanceType: "t3.large"},
+	}
+
+	tests := []struct {
+		name string
+		sel  instanceSelector
+		inst profileInstance
+		want bool
+	}{
+		{name: "empty selector", sel: instanceSelector{}, inst: inst, want: true},
+		{name: "name glob", sel: instanceSelector{"name": "web-*"}, inst: inst, want: true},
+		{name: "other name", sel: instanceSelector{"name": "db-*"}, inst: inst, want: false},
+		{name: "type glob", sel: instanceSelector{"type": "t3.*"}, inst: inst, want: true},
+		{name: "type without status", sel: instanceSelector{"type": "t3.*"}, inst: profileInstance{Name: "web-1", Outputs: inst.Outputs}, want: false},
+		{name: "userdata", sel: instanceSelector{"userdata": "docker"}, inst: inst, want: true},
+		{name: "tag", sel: instanceSelector{"team": "data"}, inst: inst, want: true},
+		{name: "tag glob", sel: instanceSelector{"env": "pr*"}, inst: inst, want: true},
+		{name: "other tag value", sel: instanceSelector{"team": "web"}, inst: inst, want: false},
+		{name: "missing tag", sel: instanceSelector{"owner": "*"}, inst: inst, want: false},
+		{name: "all terms must match", sel: instanceSelector{"name": "web-*", "team": "web"}, inst: inst, want: false},
+		{name: "instance without spec", sel: instanceSelector{"team": "data"}, inst: profileInstance{Name: "old"}, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.sel.Matches(tc.inst); got != tc.want {
+				t.Errorf("Matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
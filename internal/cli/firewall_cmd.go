@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"privatebox/internal/config"
+	"privatebox/internal/orchestration"
+	"privatebox/internal/providers"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/urfave/cli/v3"
+)
+
+// myIPURL returns the caller's public IPv4 address as plain text.
+const myIPURL = "https://checkip.amazonaws.com"
+
+// lookupMyIP returns the caller's public IPv4 address as a /32 CIDR block.
+func lookupMyIP(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, myIPURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", config.MyIP, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", config.MyIP, err)
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if resp.StatusCode != http.StatusOK || ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("failed to resolve %s: unexpected response from %s", config.MyIP, myIPURL)
+	}
+	return ip.String() + "/32", nil
+}
+
+// applySpec creates the instance from spec, with config.MyIP resolved to the
+// current address.
+func applySpec(ctx context.Context, mgr *orchestration.StackManager, cfg *config.Profile, spec providers.InstanceSpec) (auto.UpResult, error) {
+	spec, err := prepareSpec(ctx, cfg, spec)
+	if err != nil {
+		return auto.UpResult{}, err
+	}
+	return mgr.Up(ctx, spec)
+}
+
+// updateSpec updates an existing instance to spec, see previewUpdate.
+func updateSpec(ctx context.Context, mgr *orchestration.StackManager, cfg *config.Profile, profileName string, spec providers.InstanceSpec) (auto.UpResult, error) {
+	spec, err := previewUpdate(ctx, mgr, cfg, profileName, spec)
+	if err != nil {
+		return auto.UpResult{}, err
+	}
+	return mgr.Up(ctx, spec)
+}
+
+// previewUpdate checks that an existing instance can be updated to spec and returns
+// the spec to apply. The instance program also reads the profile (region, key pair,
+// spot, user data, egress rules), so the update must run with the profile the instance
+// was created with, and it is refused when it would replace or delete resources, e.g.
+// because that profile changed since.
+func previewUpdate(ctx context.Context, mgr *orchestration.StackManager, cfg *config.Profile, profileName string, spec providers.InstanceSpec) (providers.InstanceSpec, error) {
+	if spec.ProfileName != "" && spec.ProfileName != profileName {
+		return spec, fmt.Errorf("instance '%s' belongs to profile '%s', use --profile %s", spec.Name, spec.ProfileName, spec.ProfileName)
+	}
+	spec, err := prepareSpec(ctx, cfg, spec)
+	if err != nil {
+		return spec, err
+	}
+
+	preview, err := mgr.Preview(ctx, spec)
+	if err != nil {
+		return spec, err
+	}
+	if n := orchestration.DestructiveChanges(preview.ChangeSummary); n > 0 {
+		return spec, fmt.Errorf("updating '%s' would replace or delete %d resource(s), check whether profile '%s' changed since the instance was created", spec.Name, n, profileName)
+	}
+	return spec, nil
+}
+
+// prepareSpec fills in the settings instances created by older versions don't keep
+// (firewall rules, hibernation, spot, idle shutdown) from the profile, as they were launched with, and
+// resolves config.MyIP unless the spec already has the address it was last resolved to.
+func prepareSpec(ctx context.Context, cfg *config.Profile, spec providers.InstanceSpec) (providers.InstanceSpec, error) {
+	if spec.IngressRules == nil {
+		spec.IngressRules = cfg.LegacyIngressRulesFor(spec.ConnectMode)
+	}
+	if spec.Hibernation == nil {
+		hibernation := cfg.HibernationEnabled()
+		spec.Hibernation = &hibernation
+	}
+	if spec.Spot == nil {
+		spec.Spot = launchSpot(cfg)
+	}
+	if spec.IdleShutdown == nil {
+		spec.IdleShutdown = launchIdleShutdown(cfg)
+	}
+	if spec.MyIP == "" && (config.UsesMyIP(spec.IngressRules) || config.UsesMyIP(cfg.AWS.EgressRules)) {
+		ip, err := lookupMyIP(ctx)
+		if err != nil {
+			return spec, err
+		}
+		spec.MyIP = ip
+	}
+	return spec, nil
+}
+
+func refreshFirewall(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+
+	mgr, cfg, profileName, _, err := getStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+	spec, err := storedSpec(ctx, mgr)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the address again
+	spec.MyIP = ""
+	res, err := updateSpec(ctx, mgr, cfg, profileName, spec)
+	if err != nil {
+		return err
+	}
+
+	updated, _ := res.Outputs[providers.SpecOutput].Value.(string)
+	if spec, err = providers.ParseSpec(updated); err == nil && spec.MyIP != "" {
+		fmt.Printf("Firewall of '%s' updated, %s is %s.\n", name, config.MyIP, spec.MyIP)
+		return nil
+	}
+	fmt.Printf("Firewall of '%s' updated.\n", name)
+	return nil
+}
+
+// firewallRules loads the stored spec of the instance named by the first argument,
+// with the active profile and its name. Instances created by older versions get the
+// rules they were created with.
+func firewallRules(ctx context.Context, cmd *cli.Command) (*orchestration.StackManager, *config.Profile, string, providers.InstanceSpec, error) {
+	name := cmd.Args().First()
+	if name == "" {
+		return nil, nil, "", providers.InstanceSpec{}, fmt.Errorf("instance name is required")
+	}
+
+	mgr, cfg, profileName, _, err := getStackManager(cmd, name)
+	if err != nil {
+		return nil, nil, "", providers.InstanceSpec{}, err
+	}
+	spec, err := storedSpec(ctx, mgr)
+	if err != nil {
+		return nil, nil, "", providers.InstanceSpec{}, err
+	}
+	if spec.IngressRules == nil {
+		spec.IngressRules = cfg.LegacyIngressRulesFor(spec.ConnectMode)
+	}
+	if spec.Hibernation == nil {
+		hibernation := cfg.HibernationEnabled()
+		spec.Hibernation = &hibernation
+	}
+	if spec.Spot == nil {
+		spec.Spot = launchSpot(cfg)
+	}
+	if spec.IdleShutdown == nil {
+		spec.IdleShutdown = launchIdleShutdown(cfg)
+	}
+	return mgr, cfg, profileName, spec, nil
+}
+
+// ruleFromArgs builds a rule from the PROTOCOL/PORTS argument and the --from flags.
+func ruleFromArgs(cmd *cli.Command, defaultSources ...string) (config.SecurityGroupRule, error) {
+	if cmd.Args().Len() < 2 {
+		return config.SecurityGroupRule{}, fmt.Errorf("rule is required, e.g. tcp/8080")
+	}
+	rule, err := config.ParsePorts(cmd.Args().Get(1))
+	if err != nil {
+		return rule, err
+	}
+
+	sources := cmd.StringSlice("from")
+	if len(sources) == 0 {
+		sources = defaultSources
+	}
+	for _, s := range sources {
+		source, err := config.ParseSource(s)
+		if err != nil {
+			return rule, err
+		}
+		rule.CidrBlocks = append(rule.CidrBlocks, source)
+	}
+	return rule, nil
+}
+
+func listFirewall(ctx context.Context, cmd *cli.Command) error {
+	_, _, _, spec, err := firewallRules(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if len(spec.IngressRules) == 0 {
+		fmt.Printf("Instance '%s' accepts no inbound connections.\n", spec.Name)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"RULE", "FROM"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	for _, r := range spec.IngressRules {
+		sources := make([]string, 0, len(r.CidrBlocks))
+		for _, c := range r.CidrBlocks {
+			if c == config.MyIP && spec.MyIP != "" {
+				c = fmt.Sprintf("%s (%s)", c, spec.MyIP)
+			}
+			sources = append(sources, c)
+		}
+		table.Append([]string{config.FormatPorts(r), strings.Join(sources, ", ")})
+	}
+	table.Render()
+	return nil
+}
+
+func allowFirewall(ctx context.Context, cmd *cli.Command) error {
+	mgr, cfg, profileName, spec, err := firewallRules(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	rule, err := ruleFromArgs(cmd, config.MyIP)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	if spec.IngressRules, changed = config.AllowRule(spec.IngressRules, rule); !changed {
+		fmt.Printf("Instance '%s' already allows %s from %s.\n", spec.Name, config.FormatPorts(rule), strings.Join(rule.CidrBlocks, ", "))
+		return nil
+	}
+	if _, err := updateSpec(ctx, mgr, cfg, profileName, spec); err != nil {
+		return err
+	}
+	fmt.Printf("Instance '%s' allows %s from %s.\n", spec.Name, config.FormatPorts(rule), strings.Join(rule.CidrBlocks, ", "))
+	return nil
+}
+
+func revokeFirewall(ctx context.Context, cmd *cli.Command) error {
+	mgr, cfg, profileName, spec, err := firewallRules(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	rule, err := ruleFromArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	if spec.IngressRules, changed = config.RevokeRule(spec.IngressRules, rule); !changed {
+		return fmt.Errorf("instance '%s' has no matching rule for %s", spec.Name, config.FormatPorts(rule))
+	}
+	if _, err := updateSpec(ctx, mgr, cfg, profileName, spec); err != nil {
+		return err
+	}
+	fmt.Printf("Rule %s of '%s' revoked.\n", config.FormatPorts(rule), spec.Name)
+	return nil
+}
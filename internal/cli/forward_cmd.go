@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// forwardRetryDelay is how long forward waits before reconnecting a dropped tunnel.
+	forwardRetryDelay = 5 * time.Second
+	// forwardSetupTime is how long the first tunnel must stay up to count as set up.
+	// If it fails sooner the error is permanent (e.g. the local port is in use).
+	forwardSetupTime = 10 * time.Second
+)
+
+// portForward maps a port on the instance to a local port.
+type portForward struct {
+	Remote int
+	Local  int
+}
+
+func (f portForward) String() string {
+	return fmt.Sprintf("localhost:%d -> %d", f.Local, f.Remote)
+}
+
+// parsePortForward parses a REMOTE[:LOCAL] argument. The local port defaults to the remote one.
+func parsePortForward(arg string) (portForward, error) {
+	rawRemote, rawLocal, hasLocal := strings.Cut(arg, ":")
+	remote, err := strconv.Atoi(rawRemote)
+	local := remote
+	if err == nil && hasLocal {
+		local, err = strconv.Atoi(rawLocal)
+	}
+	if err != nil || remote < 1 || remote > 65535 || local < 1 || local > 65535 {
+		return portForward{}, fmt.Errorf("invalid port %q, expected PORT or PORT:LOCAL_PORT", arg)
+	}
+	return portForward{Remote: remote, Local: local}, nil
+}
+
+func forwardPorts(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+	if cmd.Args().Len() < 2 {
+		return fmt.Errorf("at least one port is required, e.g. 8080 or 8080:18080")
+	}
+
+	var forwards []portForward
+	for _, arg := range cmd.Args().Slice()[1:] {
+		f, err := parsePortForward(arg)
+		if err != nil {
+			return err
+		}
+		forwards = append(forwards, f)
+	}
+
+	opts := connectOptionsFromFlags(cmd)
+	conn, err := resolveConnection(ctx, cmd, name, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, f := range forwards {
+		fmt.Printf("Forwarding %s on '%s'\n", f, name)
+	}
+	fmt.Println("Press Ctrl-C to stop.")
+
+	// Reconnects look up the (possibly changed) address again, but don't start a
+	// stopped instance
+	opts.Start, opts.Prompt = false, false
+	tunnel := func(f *portForward) error {
+		current := conn
+		return runTunnel(ctx, func(reconnect bool) (*exec.Cmd, error) {
+			if reconnect {
+				var err error
+				if current, err = resolveConnection(ctx, cmd, name, opts); err != nil {
+					return nil, err
+				}
+			}
+			if f != nil {
+				return ssmForwardCommand(ctx, current, *f), nil
+			}
+			return sshForwardCommand(ctx, current, forwards), nil
+		})
+	}
+
+	// A single ssh process carries all forwards; SSM sessions forward one port each
+	if !conn.SSM() {
+		return tunnel(nil)
+	}
+	errs := make([]error, len(forwards))
+	var wg sync.WaitGroup
+	for i := range forwards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tunnel(&forwards[i]); err != nil {
+				errs[i] = fmt.Errorf("forwarding %s: %w", forwards[i], err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runTunnel runs the command built by start until the context is cancelled,
+// restarting it whenever it exits. It gives up if the first command fails within
+// forwardSetupTime, as retrying won't help.
+func runTunnel(ctx context.Context, start func(reconnect bool) (*exec.Cmd, error)) error {
+	for reconnect := false; ; reconnect = true {
+		started := time.Now()
+		c, err := start(reconnect)
+		if err == nil {
+			err = c.Run()
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !reconnect && time.Since(started) < forwardSetupTime {
+			return fmt.Errorf("tunnel failed: %w", err)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Tunnel closed (%v), reconnecting in %s...\n", err, forwardRetryDelay)
+		} else {
+			fmt.Fprintf(os.Stderr, "Tunnel closed, reconnecting in %s...\n", forwardRetryDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(forwardRetryDelay):
+		}
+	}
+}
+
+// sshForwardCommand returns an ssh process that forwards all ports and exits when
+// the connection drops.
+func sshForwardCommand(ctx context.Context, conn *connection, forwards []portForward) *exec.Cmd {
+	opts := []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+	}
+	for _, f := range forwards {
+		opts = append(opts, "-L", fmt.Sprintf("%d:localhost:%d", f.Local, f.Remote))
+	}
+
+	//nolint:gosec // Arguments are derived from the instance's connection details
+	c := exec.CommandContext(ctx, "ssh", conn.SSHArgs(opts...)...)
+	c.Env = conn.Env()
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c
+}
+
+// ssmForwardCommand returns a Session Manager port forwarding session for one port.
+func ssmForwardCommand(ctx context.Context, conn *connection, f portForward) *exec.Cmd {
+	args := []string{
+		"ssm", "start-session",
+		"--target", conn.InstanceID,
+		"--document-name", "AWS-StartPortForwardingSession",
+		"--parameters", fmt.Sprintf("portNumber=%d,localPortNumber=%d", f.Remote, f.Local),
+	}
+	if conn.Profile.Region != "" {
+		args = append(args, "--region", conn.Profile.Region)
+	}
+
+	//nolint:gosec // Arguments are derived from the instance's connection details
+	c := exec.CommandContext(ctx, "aws", args...)
+	c.Env = conn.Env()
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c
+}
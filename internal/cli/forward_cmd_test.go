@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParsePortForward(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    portForward
+		wantErr bool
+	}{
+		{arg: "8080", want: portForward{Remote: 8080, Local: 8080}},
+		{arg: "8080:18080", want: portForward{Remote: 8080, Local: 18080}},
+		{arg: "1:65535", want: portForward{Remote: 1, Local: 65535}},
+		{arg: "", wantErr: true},
+		{arg: "http", wantErr: true},
+		{arg: "8080:", wantErr: true},
+		{arg: ":8080", wantErr: true},
+		{arg: "0", wantErr: true},
+		{arg: "65536", wantErr: true},
+		{arg: "8080:70000", wantErr: true},
+		{arg: "8080:18080:1", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.arg, func(t *testing.T) {
+			got, err := parsePortForward(tc.arg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parsePortForward(%q) error = %v, wantErr %v", tc.arg, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("parsePortForward(%q) = %+v, want %+v", tc.arg, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunTunnel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	t.Run("first attempt fails", func(t *testing.T) {
+		attempts := 0
+		err := runTunnel(context.Background(), func(bool) (*exec.Cmd, error) {
+			attempts++
+			return exec.Command("sh", "-c", "exit 255"), nil
+		})
+		if err == nil {
+			t.Fatal("runTunnel() error = nil, want the first attempt's error")
+		}
+		if attempts != 1 {
+			t.Errorf("runTunnel() made %d attempts, want 1", attempts)
+		}
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := runTunnel(ctx, func(bool) (*exec.Cmd, error) {
+			return exec.CommandContext(ctx, "sh", "-c", "sleep 5"), nil
+		})
+		if err != nil {
+			t.Errorf("runTunnel() error = %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"privatebox/internal/config"
+	"privatebox/internal/images"
+	"privatebox/internal/providers"
+	"privatebox/internal/providers/aws"
+	"slices"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v3"
+)
+
+// resolveArchitecture returns the instance type of a new instance (cfg.AWS.InstanceType)
+// and the architecture inferred from it. If an architecture is requested
+// (create --arch) and the type was not set explicitly, a type of that architecture
+// resembling the profile's is chosen instead.
+func resolveArchitecture(ctx context.Context, cfg *config.Profile, provider providers.CloudProvider, requested string, typeSet bool) (*providers.InstanceType, string, error) {
+	want := ""
+	if requested != "" {
+		var err error
+		if want, err = images.ParseArch(requested); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Also validates the type before anything is provisioned
+	info, err := provider.DescribeInstanceType(ctx, cfg.AWS.InstanceType)
+	if err != nil {
+		return nil, "", err
+	}
+	if want != "" && !slices.Contains(info.Architectures, want) {
+		if typeSet {
+			return nil, "", fmt.Errorf("instance type %s does not support %s", info.Name, want)
+		}
+		cfg.AWS.InstanceType = images.InstanceTypeFor(info.Name, want)
+		fmt.Printf("Using instance type %s for %s.\n", cfg.AWS.InstanceType, want)
+		if info, err = provider.DescribeInstanceType(ctx, cfg.AWS.InstanceType); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if want != "" {
+		return info, want, nil
+	}
+	arch, err := images.Architecture(info.Architectures)
+	return info, arch, err
+}
+
+// resolveImage returns the image a new instance of the profile is launched from,
+// and its SSH user: the profile's ssh_user, or the one detected from the image.
+func resolveImage(ctx context.Context, cfg *config.Profile, provider providers.CloudProvider, arch string) (*providers.Image, string, error) {
+	image, err := provider.ResolveImage(ctx, providers.ImageQuery{ID: cfg.AWS.AMI, Alias: cfg.Image, Architecture: arch})
+	if err != nil {
+		return nil, "", err
+	}
+	if image.Architecture != arch {
+		return nil, "", fmt.Errorf("image %s is built for %s, but instance type %s needs %s", image.ID, image.Architecture, cfg.AWS.InstanceType, arch)
+	}
+	if cfg.SSHUser != "" {
+		return image, cfg.SSHUser, nil
+	}
+
+	user, ok := images.SSHUser(image.Name, image.OwnerID, image.Platform)
+	if !ok {
+		fmt.Printf("Could not detect the SSH user of image %s (%s), using '%s'. Set ssh_user in the profile to override it.\n", image.ID, image.Name, user)
+	}
+	return image, user, nil
+}
+
+// listImages shows what each image alias of the catalog resolves to in the
+// profile's region.
+func listImages(ctx context.Context, cmd *cli.Command) error {
+	profile, _, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+	if profile.Provider != providerAWS {
+		return fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+	provider := aws.New(*profile)
+
+	archs := []string{images.ArchX86_64, images.ArchARM64}
+	if raw := cmd.String("arch"); raw != "" {
+		arch, err := images.ParseArch(raw)
+		if err != nil {
+			return err
+		}
+		archs = []string{arch}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"IMAGE", "ARCH", "AMI", "NAME", "CREATED", "SSH USER"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+
+	for _, alias := range images.Aliases() {
+		label := alias
+		if alias == images.DefaultImage {
+			label += " (default)"
+		}
+		for _, arch := range archs {
+			image, err := provider.ResolveImage(ctx, providers.ImageQuery{Alias: alias, Architecture: arch})
+			if err != nil {
+				table.Append([]string{label, arch, "-", "Error: " + err.Error(), "", ""})
+				continue
+			}
+			user, _ := images.SSHUser(image.Name, image.OwnerID, image.Platform)
+			created, _, _ := strings.Cut(image.CreationDate, "T")
+			table.Append([]string{label, arch, image.ID, image.Name, created, user})
+		}
+	}
+
+	fmt.Printf("Images in %s:\n", profile.Region)
+	table.Render()
+	return nil
+}
@@ -2,26 +2,39 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"privatebox/internal/config"
 	"privatebox/internal/orchestration"
+	"privatebox/internal/pricing"
 	"privatebox/internal/providers"
 	"privatebox/internal/providers/aws"
+	"privatebox/internal/schedule"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/manifoldco/promptui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v3"
 )
 
-const providerAWS = "aws"
+const (
+	providerAWS        = "aws"
+	capacityRetryDelay = 30 * time.Second
+)
 
 // GetRootCommands returns the root-level CLI commands for managing instances.
 func GetRootCommands() []*cli.Command {
 	profileFlag := &cli.StringFlag{Name: "profile", Usage: "Configuration profile to use"}
+	timeoutFlag := &cli.DurationFlag{Name: "timeout", Value: defaultWaitTimeout, Usage: "Maximum time to wait"}
+	forceFlag := &cli.BoolFlag{Name: "force", Usage: "Ignore the profile's budget limits"}
+	retriesFlag := &cli.IntFlag{Name: "retries", Value: 3, Usage: "Times to retry starting when no capacity is available"}
+	waitReadyFlag := &cli.BoolFlag{Name: "wait", Value: true, Usage: "Wait until the instance is running and accepting SSH connections"}
 
 	return []*cli.Command{
 		{
@@ -30,7 +43,14 @@ func GetRootCommands() []*cli.Command {
 			ArgsUsage: "<name>",
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "type", Usage: "Instance type (e.g. t3.small)"},
+				&cli.StringFlag{Name: "arch", Usage: "CPU architecture (x86_64 or arm64); picks a matching instance type unless --type is set"},
 				&cli.StringFlag{Name: "user-data", Usage: "Path to user-data script"},
+				&cli.StringFlag{Name: "schedule", Usage: "Start/stop window, e.g. \"weekdays 08:00-19:00 America/New_York\" (overrides the profile)"},
+				&cli.StringFlag{Name: "attach-home", Usage: "Attach (and create if needed) the named persistent volume"},
+				&cli.StringSliceFlag{Name: "tag", Usage: "Tag the instance, as KEY=VALUE (repeatable, added to the profile's tags)"},
+				waitReadyFlag,
+				timeoutFlag,
+				forceFlag,
 				profileFlag,
 			},
 			Action: createInstance,
@@ -50,28 +70,266 @@ func GetRootCommands() []*cli.Command {
 			Flags:     []cli.Flag{profileFlag},
 			Action:    listInstance,
 		},
+		{
+			Name:      "status",
+			Usage:     "Show detailed status of an instance",
+			ArgsUsage: "[name]",
+			Flags:     []cli.Flag{profileFlag},
+			Action:    statusInstance,
+		},
+		{
+			Name:      "cost",
+			Usage:     "Estimate hourly, monthly and month-to-date cost of instances",
+			ArgsUsage: "[name]",
+			Flags:     []cli.Flag{profileFlag},
+			Action:    showCost,
+			Commands: []*cli.Command{
+				{
+					Name:   "refresh",
+					Usage:  "Refresh the price table for the profile's region from the AWS Price List API",
+					Flags:  []cli.Flag{profileFlag},
+					Action: refreshPrices,
+				},
+			},
+		},
 		{
 			Name:      "connect",
 			Usage:     "Connect (SSH) to an instance",
 			ArgsUsage: "[name]",
 			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "up", Usage: "Start the instance without asking if it is stopped"},
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
 				profileFlag,
 			},
 			Action: connectInstance,
 		},
+		{
+			Name:      "cp",
+			Usage:     "Copy files to or from an instance (scp)",
+			ArgsUsage: "<local> <name>:<path> | <name>:<path> <local>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "recursive", Aliases: []string{"r"}, Usage: "Copy directories (automatic for local directories)"},
+				&cli.BoolFlag{Name: "up", Usage: "Start the instance without asking if it is stopped"},
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
+				profileFlag,
+			},
+			Action: copyFiles,
+		},
+		{
+			Name:      "sync",
+			Usage:     "Sync a directory to or from an instance (rsync)",
+			ArgsUsage: "<dir> <name>:<dir> | <name>:<dir> <dir>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "watch", Usage: "Keep syncing a local directory whenever it changes"},
+				&cli.BoolFlag{Name: "delete", Usage: "Delete files in the destination that don't exist in the source"},
+				&cli.StringSliceFlag{Name: "exclude", Usage: "Pattern of files to skip (repeatable), e.g. node_modules"},
+				&cli.BoolFlag{Name: "up", Usage: "Start the instance without asking if it is stopped"},
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
+				profileFlag,
+			},
+			Action: syncFiles,
+		},
+		{
+			Name:      "exec",
+			Usage:     "Run a command on one or more instances",
+			ArgsUsage: "<name> -- <command> | --all|--selector SELECTOR -- <command>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "all", Usage: "Run on all instances of the profile"},
+				&cli.StringFlag{Name: "selector", Aliases: []string{"l"}, Usage: "Run on matching instances, e.g. \"name=web-*,team=data\" (name, type, userdata or tags)"},
+				&cli.IntFlag{Name: "parallel", Value: 10, Usage: "Maximum number of instances to run on at once"},
+				profileFlag,
+			},
+			Action: execCommand,
+		},
+		{
+			Name:      "forward",
+			Usage:     "Forward ports of an instance to localhost until interrupted",
+			ArgsUsage: "<name> <port[:local_port]>...",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "up", Usage: "Start the instance without asking if it is stopped"},
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
+				profileFlag,
+			},
+			Action: forwardPorts,
+		},
 		{
 			Name:      "up",
 			Usage:     "Start an instance",
 			ArgsUsage: "[name]",
-			Flags:     []cli.Flag{profileFlag},
-			Action:    upInstance,
+			Flags: []cli.Flag{
+				waitReadyFlag,
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
+				profileFlag,
+			},
+			Action: upInstance,
+		},
+		{
+			Name:      "resize",
+			Usage:     "Change an instance's type (stops and restarts it)",
+			ArgsUsage: "<name> <type>",
+			Flags: []cli.Flag{
+				timeoutFlag,
+				retriesFlag,
+				forceFlag,
+				profileFlag,
+			},
+			Action: resizeInstance,
 		},
 		{
 			Name:      "down",
 			Usage:     "Stop an instance",
 			ArgsUsage: "[name]",
-			Flags:     []cli.Flag{profileFlag},
-			Action:    downInstance,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "hibernate", Usage: "Hibernate instead of stopping (requires aws.hibernation in the profile at create time)"},
+				&cli.BoolFlag{Name: "wait", Usage: "Wait until the instance is stopped"},
+				timeoutFlag,
+				profileFlag,
+			},
+			Action: downInstance,
+		},
+		{
+			Name:  "volume",
+			Usage: "Manage volumes",
+			Commands: []*cli.Command{
+				{
+					Name:      "resize",
+					Usage:     "Grow an instance's root or data volumes in place",
+					ArgsUsage: "<name>",
+					Flags: []cli.Flag{
+						&cli.IntFlag{Name: "root", Usage: "New root volume size in GiB"},
+						&cli.StringSliceFlag{Name: "data", Usage: "New data volume size as MOUNT_POINT=GiB (repeatable), e.g. /data=200"},
+						profileFlag,
+					},
+					Action: resizeVolumes,
+				},
+				{
+					Name:      "create",
+					Usage:     "Create a persistent volume from the profile's persistent_volume settings",
+					ArgsUsage: "<volume>",
+					Flags:     []cli.Flag{profileFlag},
+					Action:    createVolume,
+				},
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List persistent volumes",
+					Flags:   []cli.Flag{profileFlag},
+					Action:  listVolumes,
+				},
+				{
+					Name:      "destroy",
+					Usage:     "Delete a detached persistent volume and its data",
+					ArgsUsage: "<volume>",
+					Flags:     []cli.Flag{profileFlag},
+					Action:    destroyVolume,
+				},
+			},
+		},
+		{
+			Name:  "ssh-config",
+			Usage: "Write an ssh config file with a 'privatebox-<name>' host for every instance",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "install", Usage: "Include the file from ~/.ssh/config"},
+				&cli.BoolFlag{Name: "print", Usage: "Print the configuration instead of writing it"},
+			},
+			Action: writeSSHConfig,
+		},
+		{
+			Name:  "firewall",
+			Usage: "Manage an instance's firewall rules",
+			Commands: []*cli.Command{
+				{
+					Name:      "list",
+					Aliases:   []string{"ls"},
+					Usage:     "List an instance's inbound rules",
+					ArgsUsage: "<name>",
+					Flags:     []cli.Flag{profileFlag},
+					Action:    listFirewall,
+				},
+				{
+					Name:      "allow",
+					Usage:     "Allow inbound traffic, e.g. 'allow my-vm tcp/8080 --from @myip'",
+					ArgsUsage: "<name> <tcp|udp/PORT[-PORT]|icmp|all>",
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{Name: "from", Usage: "Source address, CIDR block or @myip (repeatable, default: @myip)"},
+						profileFlag,
+					},
+					Action: allowFirewall,
+				},
+				{
+					Name:      "revoke",
+					Usage:     "Remove an inbound rule, or only some of its sources with --from",
+					ArgsUsage: "<name> <tcp|udp/PORT[-PORT]|icmp|all>",
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{Name: "from", Usage: "Source to remove (repeatable, default: the whole rule)"},
+						profileFlag,
+					},
+					Action: revokeFirewall,
+				},
+				{
+					Name:      "refresh",
+					Usage:     "Update rules using @myip to your current address",
+					ArgsUsage: "<name>",
+					Flags:     []cli.Flag{profileFlag},
+					Action:    refreshFirewall,
+				},
+			},
+		},
+		{
+			Name:  "network",
+			Usage: "Manage the profile's dedicated network (aws.create_vpc)",
+			Commands: []*cli.Command{
+				{
+					Name:   "create",
+					Usage:  "Create the dedicated network (done automatically by create)",
+					Flags:  []cli.Flag{profileFlag},
+					Action: createNetwork,
+				},
+				{
+					Name:   "show",
+					Usage:  "Show the dedicated network",
+					Flags:  []cli.Flag{profileFlag},
+					Action: showNetwork,
+				},
+				{
+					Name:   "destroy",
+					Usage:  "Delete the dedicated network (after all its instances)",
+					Flags:  []cli.Flag{profileFlag},
+					Action: destroyNetwork,
+				},
+			},
+		},
+		{
+			Name:  "images",
+			Usage: "List the image aliases of the 'image' setting and what they resolve to",
+			Flags: []cli.Flag{
+				profileFlag,
+				&cli.StringFlag{Name: "arch", Usage: "Only show images for this architecture (x86_64 or arm64)"},
+			},
+			Action: listImages,
+		},
+		{
+			Name:  "types",
+			Usage: "List instance types offered in the profile's region, cheapest first",
+			Flags: []cli.Flag{
+				profileFlag,
+				&cli.IntFlag{Name: "min-cpu", Usage: "Minimum number of vCPUs"},
+				&cli.FloatFlag{Name: "min-mem", Usage: "Minimum memory in GiB"},
+				&cli.StringFlag{Name: "arch", Usage: "Architecture (x86_64 or arm64)"},
+				&cli.FloatFlag{Name: "max-price", Usage: "Maximum on-demand price in USD/hour"},
+				&cli.IntFlag{Name: "limit", Value: 25, Usage: "Maximum number of types to show (0: all)"},
+			},
+			Action: listInstanceTypes,
 		},
 	}
 }
@@ -107,18 +365,24 @@ func loadProfile(cmd *cli.Command) (*config.Profile, string, error) {
 	return &profile, profileName, nil
 }
 
+// newProvider returns the cloud provider of the profile.
+func newProvider(profile *config.Profile) (providers.CloudProvider, error) {
+	// Provider Factory (Switch based on cfg.Provider in future)
+	if profile.Provider != providerAWS {
+		return nil, fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+	return aws.New(*profile), nil
+}
+
 func getStackManager(cmd *cli.Command, instanceName string) (*orchestration.StackManager, *config.Profile, string, providers.CloudProvider, error) {
 	profile, profileName, err := loadProfile(cmd)
 	if err != nil {
 		return nil, nil, "", nil, err
 	}
 
-	// Provider Factory (Switch based on cfg.Provider in future)
-	var provider providers.CloudProvider
-	if profile.Provider == providerAWS {
-		provider = aws.New(*profile)
-	} else {
-		return nil, nil, "", nil, fmt.Errorf("unsupported provider: %s", profile.Provider)
+	provider, err := newProvider(profile)
+	if err != nil {
+		return nil, nil, "", nil, err
 	}
 
 	// Pass pointer to profile
@@ -126,13 +390,79 @@ func getStackManager(cmd *cli.Command, instanceName string) (*orchestration.Stac
 	return mgr, profile, profileName, provider, nil
 }
 
+// storedSpec returns the spec the instance was created (or last updated) with.
+func storedSpec(ctx context.Context, mgr *orchestration.StackManager) (providers.InstanceSpec, error) {
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return providers.InstanceSpec{}, fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+	raw, _ := outs[providers.SpecOutput].Value.(string)
+	return providers.ParseSpec(raw)
+}
+
+// instanceTags merges the profile's tags with KEY=VALUE arguments, which take precedence.
+func instanceTags(profileTags map[string]string, args []string) (map[string]string, error) {
+	tags := maps.Clone(profileTags)
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q, expected KEY=VALUE", arg)
+		}
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[key] = value
+	}
+	for key := range tags {
+		switch key {
+		case "Name", "UserDataName", "name", "type", "userdata":
+			return nil, fmt.Errorf("tag %q is reserved", key)
+		}
+	}
+	return tags, nil
+}
+
+// launchSpot returns the profile's spot settings to keep with a new instance; a
+// disabled config for on-demand instances.
+func launchSpot(cfg *config.Profile) *config.SpotConfig {
+	if cfg.AWS.Spot == nil {
+		return &config.SpotConfig{}
+	}
+	spot := *cfg.AWS.Spot
+	return &spot
+}
+
+// launchIdleShutdown returns the profile's idle policy to keep with a new instance;
+// an empty policy if the profile has none.
+func launchIdleShutdown(cfg *config.Profile) *config.IdleShutdown {
+	if cfg.IdleShutdown == nil {
+		return &config.IdleShutdown{}
+	}
+	idle := *cfg.IdleShutdown
+	return &idle
+}
+
+// checkHibernation checks that the root volume can hold the instance's RAM, which
+// hibernation saves to it.
+func checkHibernation(instanceType *providers.InstanceType, root *config.RootVolume, image *providers.Image) error {
+	size := image.RootSizeGiB
+	if root != nil && root.SizeGiB > 0 {
+		size = root.SizeGiB
+	}
+	memGiB := int((instanceType.MemoryMiB + 1023) / 1024)
+	if size < memGiB {
+		return fmt.Errorf("hibernation needs a root volume at least as large as the RAM of %s (%d GiB), but it is %d GiB; set aws.root_volume.size", instanceType.Name, memGiB, size)
+	}
+	return nil
+}
+
 func createInstance(ctx context.Context, cmd *cli.Command) error {
 	name := cmd.Args().First()
 	if name == "" {
 		return fmt.Errorf("instance name is required")
 	}
 
-	mgr, cfg, profileName, _, err := getStackManager(cmd, name)
+	mgr, cfg, profileName, provider, err := getStackManager(cmd, name)
 	if err != nil {
 		return err
 	}
@@ -160,21 +490,135 @@ func createInstance(ctx context.Context, cmd *cli.Command) error {
 	if instanceType != "" {
 		cfg.AWS.InstanceType = instanceType
 	}
+	if cfg.AWS.InstanceType == "" {
+		cfg.AWS.InstanceType = aws.DefaultInstanceType
+	}
+
+	// Validate the schedule before provisioning anything
+	scheduleArg := cmd.String("schedule")
+	for _, raw := range []string{scheduleArg, cfg.Schedule} {
+		if raw == "" {
+			continue
+		}
+		if _, err := schedule.Parse(raw); err != nil {
+			return err
+		}
+	}
+	switch cfg.ConnectMode {
+	case "", config.ConnectModeSSH, config.ConnectModeSSM:
+	default:
+		return fmt.Errorf("invalid connect_mode %q (expected %q or %q)", cfg.ConnectMode, config.ConnectModeSSH, config.ConnectModeSSM)
+	}
+	var dnsRecord *providers.DNSRecord
+	if cfg.DNS != nil {
+		// Only privatebox updates the record, scheduled starts would leave it stale
+		if (scheduleArg != "" || cfg.Schedule != "") && !cfg.AWS.ElasticIP {
+			return fmt.Errorf("dns with a schedule requires aws.elastic_ip, so that the address does not change on scheduled starts")
+		}
+		hostname, err := cfg.DNS.Hostname(name)
+		if err != nil {
+			return err
+		}
+		dnsRecord = &providers.DNSRecord{ZoneID: cfg.DNS.ZoneID, Name: hostname, TTL: cfg.DNS.RecordTTL()}
+	}
+
+	tags, err := instanceTags(cfg.Tags, cmd.StringSlice("tag"))
+	if err != nil {
+		return err
+	}
+
+	typeInfo, arch, err := resolveArchitecture(ctx, cfg, provider, cmd.String("arch"), instanceType != "")
+	if err != nil {
+		return err
+	}
+	image, sshUser, err := resolveImage(ctx, cfg, provider, arch)
+	if err != nil {
+		return err
+	}
+
+	hibernation := cfg.HibernationEnabled()
+	if hibernation {
+		if err := checkHibernation(typeInfo, cfg.AWS.RootVolume, image); err != nil {
+			return err
+		}
+	}
 
 	spec := providers.InstanceSpec{
 		Name:         name,
 		Type:         cfg.AWS.InstanceType,
+		AMI:          image.ID,
+		SSHUser:      sshUser,
 		UserData:     userDataContent,
 		UserDataName: userDataName,
 		ProfileName:  profileName,
+		Schedule:     scheduleArg,
+		RootVolume:   cfg.AWS.RootVolume,
+		DataVolumes:  cfg.AWS.DataVolumes,
+		ConnectMode:  cfg.ConnectMode,
+		IngressRules: cfg.IngressRulesFor(cfg.ConnectMode),
+		DNS:          dnsRecord,
+		ElasticIP:    cfg.AWS.ElasticIP,
+		Hibernation:  &hibernation,
+		Spot:         launchSpot(cfg),
+		IdleShutdown: launchIdleShutdown(cfg),
+		Tags:         tags,
 	}
 
-	_, err = mgr.Up(ctx, spec)
+	if !cmd.Bool("force") {
+		if err := checkBudget(ctx, cfg, profileName, name, cfg.AWS.InstanceType); err != nil {
+			return err
+		}
+	}
+
+	// The subnet must be in a zone offering the instance type, and in the zone of
+	// the persistent volume if one is attached
+	zones := typeInfo.Zones
+	if volumeName := cmd.String("attach-home"); volumeName != "" {
+		if spec.HomeVolume, err = resolveHomeVolume(ctx, cmd, volumeName); err != nil {
+			return err
+		}
+		zone := spec.HomeVolume.AvailabilityZone
+		if !slices.Contains(typeInfo.Zones, zone) {
+			return fmt.Errorf("instance type %s is not offered in %s, the zone of volume '%s' (only in %s)", typeInfo.Name, zone, volumeName, strings.Join(typeInfo.Zones, ", "))
+		}
+		zones = []string{zone}
+	}
+
+	if spec.Network, err = resolveNetwork(ctx, cfg, profileName, provider, zones); err != nil {
+		return err
+	}
+
+	printCreateEstimate(cfg, spec)
+
+	res, err := applySpec(ctx, mgr, cfg, spec)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Instance '%s' created successfully.\n", name)
+	if dnsRecord != nil {
+		fmt.Printf("Hostname: %s\n", dnsRecord.Name)
+	}
+
+	instanceID, _ := res.Outputs["instanceID"].Value.(string)
+	if instanceID == "" {
+		return fmt.Errorf("instance ID not found in stack outputs")
+	}
+	if !cmd.Bool("wait") {
+		// The public IP is only known once the instance is running
+		if sshConfigManaged() {
+			if _, err := waitForState(ctx, provider, instanceID, "running", cmd.Duration("timeout")); err != nil {
+				return err
+			}
+			refreshSSHConfig(ctx)
+		}
+		return nil
+	}
+	if _, err := waitForReady(ctx, provider, instanceID, outputConnectMode(res.Outputs), cmd.Duration("timeout")); err != nil {
+		return err
+	}
+	refreshSSHConfig(ctx)
+	fmt.Printf("Instance '%s' is ready.\n", name)
 	return nil
 }
 
@@ -189,12 +633,19 @@ func destroyInstance(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	// Instances created by older versions have no stored spec (and no persistent volume)
+	spec, _ := storedSpec(ctx, mgr)
+
 	_, err = mgr.Destroy(ctx)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Instance '%s' destroyed.\n", name)
+	refreshSSHConfig(ctx)
+	if spec.HomeVolume != nil {
+		fmt.Printf("Volume '%s' was detached and kept.\n", spec.HomeVolume.Name)
+	}
 	return nil
 }
 
@@ -224,7 +675,7 @@ func listInstance(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"NAME", "PROFILE", "PRIVATE IP", "PUBLIC IP", "STATE"})
+	table.SetHeader([]string{"NAME", "PROFILE", "PRIVATE IP", "PUBLIC IP", "STATE", "LIFECYCLE", "NEXT SCHEDULED"})
 	table.SetBorder(false)
 	table.SetAutoWrapText(false)
 
@@ -243,7 +694,7 @@ func listInstance(ctx context.Context, cmd *cli.Command) error {
 		outs, err := mgr.GetOutputs(ctx)
 		if err != nil {
 			// If we can't get outputs (e.g. stack broken), just show empty or error
-			table.Append([]string{instName, "", "", "", "Error: " + err.Error()})
+			table.Append([]string{instName, "", "", "", "Error: " + err.Error(), "", ""})
 			continue
 		}
 
@@ -251,16 +702,21 @@ func listInstance(ctx context.Context, cmd *cli.Command) error {
 		publicIP, _ := outs["publicIP"].Value.(string)
 		privateIP, _ := outs["privateIP"].Value.(string)
 		profileName, _ := outs["profileName"].Value.(string)
+		scheduleDesc, _ := outs["schedule"].Value.(string)
+		if elasticIP, _ := outs["elasticIP"].Value.(string); elasticIP != "" {
+			publicIP = elasticIP
+		}
 
 		if profileName == "" {
 			profileName = "Unknown"
 		}
 
-		var state string
+		var state, lifecycle string
 		if id != "" {
 			status, err := provider.GetInstanceStatus(ctx, id)
 			if err == nil {
 				state = status.State
+				lifecycle = describeLifecycle(ctx, provider, status)
 			} else {
 				state = fmt.Sprintf("Error: %v", err)
 			}
@@ -268,65 +724,77 @@ func listInstance(ctx context.Context, cmd *cli.Command) error {
 			state = "Provisioning/Error"
 		}
 
-		table.Append([]string{instName, profileName, privateIP, publicIP, state})
+		table.Append([]string{instName, profileName, privateIP, publicIP, state, lifecycle, nextTransition(scheduleDesc, time.Now())})
 	}
 
 	table.Render()
 	return nil
 }
 
-func connectInstance(ctx context.Context, cmd *cli.Command) error {
-	name, err := selectInstance(ctx, cmd, "")
+// printCreateEstimate prints the expected hourly rate of a new instance.
+func printCreateEstimate(cfg *config.Profile, spec providers.InstanceSpec) {
+	table, err := loadPriceTable()
 	if err != nil {
-		return err
+		return
+	}
+	price, ok := table.InstanceHourly(cfg.Region, cfg.AWS.InstanceType)
+	if !ok {
+		fmt.Printf("Estimated cost: unknown for %s (run 'privatebox cost refresh')\n", cfg.AWS.InstanceType)
+		return
 	}
 
-	mgr, cfg, _, provider, err := getStackManager(cmd, name)
-	if err != nil {
-		return err
+	msg := fmt.Sprintf("Estimated cost: $%.4f/h for %s in %s (on-demand)", price, cfg.AWS.InstanceType, cfg.Region)
+	if spot := cfg.AWS.Spot; spot != nil && spot.Enabled {
+		msg += ", spot instances are usually cheaper"
 	}
+	ipv4 := table.PublicIPv4Hourly(cfg.Region)
+	switch {
+	case spec.ElasticIP:
+		msg += fmt.Sprintf(", $%.2f/month for the elastic IP", ipv4*pricing.HoursPerMonth)
+	case spec.Network == nil || spec.Network.AssociatePublicIP:
+		msg += fmt.Sprintf(", $%.4f/h for the public IP while running", ipv4)
+	}
+	fmt.Printf("%s, plus storage and $%.2f/month for the KMS key\n", msg, table.KMSKeyMonthly(cfg.Region))
+}
 
-	outs, err := mgr.GetOutputs(ctx)
+// nextTransition describes the next scheduled start/stop, e.g. "stop Mon 19:00 EDT".
+func nextTransition(raw string, now time.Time) string {
+	if raw == "" {
+		return ""
+	}
+	sched, err := schedule.Parse(raw)
 	if err != nil {
-		return err
+		return "invalid schedule"
 	}
+	action, at := sched.Next(now)
+	return fmt.Sprintf("%s %s", action, at.Format("Mon 15:04 MST"))
+}
 
-	ip, ok := outs["publicIP"].Value.(string)
-	if !ok {
-		return fmt.Errorf("publicIP output not found, instance might not be ready")
+func connectInstance(ctx context.Context, cmd *cli.Command) error {
+	name, err := selectInstance(ctx, cmd, "")
+	if err != nil {
+		return err
 	}
 
-	instanceID, _ := outs["instanceID"].Value.(string)
-
-	user := provider.GetSSHUser()
-	host := fmt.Sprintf("%s@%s", user, ip)
-
-	// Determine Private Key Path
-	privKeyPath := ""
-	if cfg.SSHPublicKey != "" {
-		privKeyPath = cfg.SSHPublicKey
-		privKeyPath = strings.TrimSuffix(privKeyPath, ".pub")
+	conn, err := resolveConnection(ctx, cmd, name, connectOptionsFromFlags(cmd))
+	if err != nil {
+		return err
 	}
 
 	// Determine Command Template
-	cmdTemplate := cfg.ConnectCommand
+	cmdTemplate := conn.Profile.ConnectCommand
 	if cmdTemplate == "" {
-		if privKeyPath != "" {
-			cmdTemplate = "ssh -i {key} {host}"
+		if conn.KeyPath != "" {
+			cmdTemplate = "ssh -i {key} {user}@{ip}"
 		} else {
-			cmdTemplate = "ssh {host}"
+			cmdTemplate = "ssh {user}@{ip}"
 		}
 	}
 
 	// Replace Variables
-	commandStr := cmdTemplate
-	commandStr = strings.ReplaceAll(commandStr, "{user}", user)
-	commandStr = strings.ReplaceAll(commandStr, "{ip}", ip)
-	commandStr = strings.ReplaceAll(commandStr, "{id}", instanceID)
-	commandStr = strings.ReplaceAll(commandStr, "{key}", privKeyPath)
-	commandStr = strings.ReplaceAll(commandStr, "{host}", host)
-
-	fmt.Printf("Connecting to %s (%s)...\n", name, ip)
+	commandStr := conn.Expand(cmdTemplate)
+
+	fmt.Printf("Connecting to %s (%s)...\n", name, conn.Address())
 	fmt.Printf("Command: %s\n", commandStr)
 
 	// Use sh -c to allow for complex commands (pipes, etc) and correct argument parsing by shell
@@ -335,21 +803,7 @@ func connectInstance(ctx context.Context, cmd *cli.Command) error {
 	sshCmd.Stdin = os.Stdin
 	sshCmd.Stdout = os.Stdout
 	sshCmd.Stderr = os.Stderr
-
-	// Prepare environment variables
-	env := os.Environ()
-
-	// 1. AWS Profile from config
-	if cfg.AWS.Profile != "" {
-		env = append(env, fmt.Sprintf("AWS_PROFILE=%s", cfg.AWS.Profile))
-	}
-
-	// 2. Custom environment variables from profile
-	for k, v := range cfg.Env {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
-	}
-
-	sshCmd.Env = env
+	sshCmd.Env = conn.Env()
 
 	return sshCmd.Run()
 }
@@ -360,7 +814,7 @@ func upInstance(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	mgr, _, _, provider, err := getStackManager(cmd, name)
+	mgr, cfg, profileName, provider, err := getStackManager(cmd, name)
 	if err != nil {
 		return err
 	}
@@ -375,15 +829,94 @@ func upInstance(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("instance ID not found in stack outputs")
 	}
 
+	if !cmd.Bool("force") {
+		info, err := provider.GetInstanceStatus(ctx, instanceID)
+		if err != nil {
+			return fmt.Errorf("failed to get instance status: %w", err)
+		}
+		if err := checkBudget(ctx, cfg, profileName, name, info.InstanceType); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("Starting instance '%s' (%s)...\n", name, instanceID)
-	if err := provider.StartInstance(ctx, instanceID); err != nil {
-		return fmt.Errorf("failed to start instance: %w", err)
+	if err := startInstance(ctx, provider, instanceID, cmd.Int("retries")); err != nil {
+		return err
 	}
 
-	fmt.Println("Instance start requested.")
+	if !cmd.Bool("wait") {
+		// The new public IP is only known once the instance is running
+		if outputDNSRecord(outs) != nil || sshConfigManaged() {
+			info, err := waitForState(ctx, provider, instanceID, "running", cmd.Duration("timeout"))
+			if err != nil {
+				return err
+			}
+			updateDNSRecord(ctx, provider, outs, info)
+			refreshSSHConfig(ctx)
+		}
+		fmt.Println("Instance start requested.")
+		return nil
+	}
+
+	info, err := waitForReady(ctx, provider, instanceID, outputConnectMode(outs), cmd.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+	updateDNSRecord(ctx, provider, outs, info)
+	refreshSSHConfig(ctx)
+	fmt.Printf("Instance '%s' is ready.\n", name)
 	return nil
 }
 
+// startInstance starts the instance, retrying when the provider is out of capacity
+// (common for spot instances). If all attempts fail, it suggests similar instance types.
+func startInstance(ctx context.Context, provider providers.CloudProvider, instanceID string, retries int) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = provider.StartInstance(ctx, instanceID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, providers.ErrInsufficientCapacity) {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+		if attempt >= retries {
+			break
+		}
+
+		fmt.Printf("No capacity available, retrying in %s (%d/%d)...\n", capacityRetryDelay, attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(capacityRetryDelay):
+		}
+	}
+
+	msg := fmt.Sprintf("failed to start instance: %v", err)
+	if info, statusErr := provider.GetInstanceStatus(ctx, instanceID); statusErr == nil {
+		if similar, _ := provider.SimilarInstanceTypes(ctx, info.InstanceType); len(similar) > 0 {
+			if len(similar) > 5 {
+				similar = similar[:5]
+			}
+			msg += fmt.Sprintf("\nNo %s capacity in %s right now. Similar instance types: %s",
+				info.InstanceType, info.AvailabilityZone, strings.Join(similar, ", "))
+		}
+	}
+	return errors.New(msg)
+}
+
+// describeLifecycle returns "on-demand" or "spot ($0.0123/h)".
+func describeLifecycle(ctx context.Context, provider providers.CloudProvider, info *providers.RuntimeInfo) string {
+	if info.Lifecycle != "spot" {
+		return info.Lifecycle
+	}
+	price, err := provider.GetSpotPrice(ctx, info.InstanceType, info.AvailabilityZone)
+	if err != nil {
+		return "spot"
+	}
+	return fmt.Sprintf("spot ($%.4f/h)", price)
+}
+
 func downInstance(ctx context.Context, cmd *cli.Command) error {
 	name, err := selectInstance(ctx, cmd, "running")
 	if err != nil {
@@ -405,12 +938,27 @@ func downInstance(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("instance ID not found in stack outputs")
 	}
 
-	fmt.Printf("Stopping instance '%s' (%s)...\n", name, instanceID)
-	if err := provider.StopInstance(ctx, instanceID); err != nil {
+	hibernate := cmd.Bool("hibernate")
+	if hibernate {
+		fmt.Printf("Hibernating instance '%s' (%s)...\n", name, instanceID)
+	} else {
+		fmt.Printf("Stopping instance '%s' (%s)...\n", name, instanceID)
+	}
+	if err := provider.StopInstance(ctx, instanceID, hibernate); err != nil {
 		return fmt.Errorf("failed to stop instance: %w", err)
 	}
+	// The public IP is released; stopping instances are written without it
+	defer refreshSSHConfig(ctx)
+
+	if !cmd.Bool("wait") {
+		fmt.Println("Instance stop requested.")
+		return nil
+	}
 
-	fmt.Println("Instance stop requested.")
+	if _, err := waitForState(ctx, provider, instanceID, "stopped", cmd.Duration("timeout")); err != nil {
+		return err
+	}
+	fmt.Printf("Instance '%s' is stopped.\n", name)
 	return nil
 }
 
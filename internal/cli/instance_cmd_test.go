@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"reflect"
+	"testing"
+)
+
+func TestInstanceTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		profileTags map[string]string
+		args        []string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{name: "none"},
+		{name: "profile only", profileTags: map[string]string{"team": "data"}, want: map[string]string{"team": "data"}},
+		{name: "arguments only", args: []string{"env=dev", "owner=ana"}, want: map[string]string{"env": "dev", "owner": "ana"}},
+		{
+			name:        "arguments take precedence",
+			profileTags: map[string]string{"team": "data", "env": "prod"},
+			args:        []string{"env=dev"},
+			want:        map[string]string{"team": "data", "env": "dev"},
+		},
+		{name: "empty value", args: []string{"env="}, want: map[string]string{"env": ""}},
+		{name: "value with equals sign", args: []string{"query=a=b"}, want: map[string]string{"query": "a=b"}},
+		{name: "missing value", args: []string{"env"}, wantErr: true},
+		{name: "missing key", args: []string{"=dev"}, wantErr: true},
+		{name: "reserved key", args: []string{"Name=web"}, wantErr: true},
+		{name: "reserved selector key", args: []string{"type=gpu"}, wantErr: true},
+		{name: "reserved key in profile", profileTags: map[string]string{"userdata": "x"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := instanceTags(tc.profileTags, tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("instanceTags() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("instanceTags() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInstanceTagsKeepsProfile(t *testing.T) {
+	profileTags := map[string]string{"env": "prod"}
+	if _, err := instanceTags(profileTags, []string{"env=dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if profileTags["env"] != "prod" {
+		t.Errorf("instanceTags() modified the profile's tags: %v", profileTags)
+	}
+}
+
+func TestCheckHibernation(t *testing.T) {
+	m7i := &providers.InstanceType{Name: "m7i.xlarge", MemoryMiB: 16384}
+	t3 := &providers.InstanceType{Name: "t3.micro", MemoryMiB: 1024}
+	image := &providers.Image{ID: "ami-1", RootSizeGiB: 8}
+
+	tests := []struct {
+		name         string
+		instanceType *providers.InstanceType
+		root         *config.RootVolume
+		wantErr      bool
+	}{
+		{name: "image size holds the RAM", instanceType: t3},
+		{name: "image size too small", instanceType: m7i, wantErr: true},
+		{name: "configured size holds the RAM", instanceType: m7i, root: &config.RootVolume{SizeGiB: 30}},
+		{name: "configured size equals the RAM", instanceType: m7i, root: &config.RootVolume{SizeGiB: 16}},
+		{name: "configured size too small", instanceType: m7i, root: &config.RootVolume{SizeGiB: 12}, wantErr: true},
+		{name: "root volume without size", instanceType: m7i, root: &config.RootVolume{Type: "io2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkHibernation(tc.instanceType, tc.root, image)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkHibernation() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"privatebox/internal/config"
+	"privatebox/internal/orchestration"
+	"privatebox/internal/providers"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/urfave/cli/v3"
+)
+
+// resolveNetwork selects the subnet a new instance is launched in. zones restricts
+// the subnet to availability zones (e.g. those offering the instance type).
+func resolveNetwork(ctx context.Context, cfg *config.Profile, profileName string, provider providers.CloudProvider, zones []string) (*providers.Network, error) {
+	var network *providers.Network
+	if cfg.AWS.CreateVPC {
+		outs, err := ensureNetwork(ctx, cfg, profileName, provider)
+		if err != nil {
+			return nil, err
+		}
+		if network, err = networkFromOutputs(outs, zones); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		network, err = provider.FindSubnet(ctx, providers.SubnetQuery{
+			VPCID:             cfg.AWS.VPCID,
+			SubnetID:          cfg.AWS.SubnetID,
+			Tags:              cfg.AWS.SubnetTags,
+			AvailabilityZones: zones,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Instances reached through SSM don't need a public IP, provided their subnet
+	// reaches SSM without one. Only the dedicated network is known to (through its
+	// endpoints, see ensureNetwork); elsewhere that takes associate_public_ip: false.
+	if cfg.AWS.AssociatePublicIP != nil {
+		network.AssociatePublicIP = *cfg.AWS.AssociatePublicIP
+	} else {
+		network.AssociatePublicIP = cfg.ConnectMode != config.ConnectModeSSM || !cfg.AWS.CreateVPC
+	}
+	return network, nil
+}
+
+// ensureNetwork returns the outputs of the profile's dedicated network, creating it
+// if it does not exist yet. Profiles using the SSM connect mode get SSM endpoints,
+// which are added to existing networks that lack them.
+func ensureNetwork(ctx context.Context, cfg *config.Profile, profileName string, provider providers.CloudProvider) (auto.OutputMap, error) {
+	mgr := orchestration.NewNetworkStackManager(cfg, provider, profileName)
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := cfg.ConnectMode == config.ConnectModeSSM
+	if id, _ := outs["vpcID"].Value.(string); id != "" {
+		if has, _ := outs["ssmEndpoints"].Value.(bool); has || !endpoints {
+			return outs, nil
+		}
+	}
+
+	res, err := mgr.UpNetwork(ctx, providers.NetworkSpec{Name: profileName, CIDR: cfg.AWS.VPCCIDR, SSMEndpoints: endpoints})
+	if err != nil {
+		return nil, err
+	}
+	return res.Outputs, nil
+}
+
+// networkFromOutputs picks a subnet of a dedicated network, in one of zones if set.
+func networkFromOutputs(outs auto.OutputMap, zones []string) (*providers.Network, error) {
+	vpcID, _ := outs["vpcID"].Value.(string)
+	subnets, _ := outs["subnets"].Value.(map[string]any)
+
+	candidates := make([]string, 0, len(subnets))
+	for z := range subnets {
+		if len(zones) == 0 || slices.Contains(zones, z) {
+			candidates = append(candidates, z)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("the profile's VPC has no subnet in %s", strings.Join(zones, ", "))
+	}
+	sort.Strings(candidates)
+
+	subnetID, _ := subnets[candidates[0]].(string)
+	return &providers.Network{VPCID: vpcID, SubnetID: subnetID, AvailabilityZone: candidates[0]}, nil
+}
+
+func createNetwork(ctx context.Context, cmd *cli.Command) error {
+	_, cfg, profileName, provider, err := getStackManager(cmd, "")
+	if err != nil {
+		return err
+	}
+	outs, err := ensureNetwork(ctx, cfg, profileName, provider)
+	if err != nil {
+		return err
+	}
+	return printNetwork(profileName, outs)
+}
+
+func showNetwork(ctx context.Context, cmd *cli.Command) error {
+	_, cfg, profileName, provider, err := getStackManager(cmd, "")
+	if err != nil {
+		return err
+	}
+	outs, err := orchestration.NewNetworkStackManager(cfg, provider, profileName).GetOutputs(ctx)
+	if err != nil {
+		return err
+	}
+	if id, _ := outs["vpcID"].Value.(string); id == "" {
+		fmt.Printf("Profile '%s' has no dedicated network.\n", profileName)
+		return nil
+	}
+	return printNetwork(profileName, outs)
+}
+
+func printNetwork(profileName string, outs auto.OutputMap) error {
+	vpcID, _ := outs["vpcID"].Value.(string)
+	subnets, _ := outs["subnets"].Value.(map[string]any)
+
+	fmt.Printf("Network of profile '%s': %s\n", profileName, vpcID)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ZONE", "SUBNET"})
+	table.SetBorder(false)
+
+	zones := make([]string, 0, len(subnets))
+	for z := range subnets {
+		zones = append(zones, z)
+	}
+	sort.Strings(zones)
+	for _, z := range zones {
+		id, _ := subnets[z].(string)
+		table.Append([]string{z, id})
+	}
+	table.Render()
+	return nil
+}
+
+func destroyNetwork(ctx context.Context, cmd *cli.Command) error {
+	_, cfg, profileName, provider, err := getStackManager(cmd, "")
+	if err != nil {
+		return err
+	}
+	if !confirm(fmt.Sprintf("Delete the dedicated network of profile '%s'", profileName)) {
+		return fmt.Errorf("aborted")
+	}
+	if _, err := orchestration.NewNetworkStackManager(cfg, provider, profileName).Destroy(ctx); err != nil {
+		return fmt.Errorf("%w (destroy the profile's instances first)", err)
+	}
+	fmt.Printf("Network of profile '%s' destroyed.\n", profileName)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"privatebox/internal/providers"
+	"reflect"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+func TestNetworkFromOutputs(t *testing.T) {
+	outs := auto.OutputMap{
+		"vpcID": {Value: "vpc-1"},
+		"subnets": {Value: map[string]any{
+			"us-east-1c": "subnet-c",
+			"us-east-1a": "subnet-a",
+			"us-east-1b": "subnet-b",
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		outs    auto.OutputMap
+		zones   []string
+		want    *providers.Network
+		wantErr bool
+	}{
+		{
+			name: "first zone",
+			outs: outs,
+			want: &providers.Network{VPCID: "vpc-1", SubnetID: "subnet-a", AvailabilityZone: "us-east-1a"},
+		},
+		{
+			name:  "single zone",
+			outs:  outs,
+			zones: []string{"us-east-1b"},
+			want:  &providers.Network{VPCID: "vpc-1", SubnetID: "subnet-b", AvailabilityZone: "us-east-1b"},
+		},
+		{
+			name:  "first allowed zone",
+			outs:  outs,
+			zones: []string{"us-east-1d", "us-east-1c", "us-east-1b"},
+			want:  &providers.Network{VPCID: "vpc-1", SubnetID: "subnet-b", AvailabilityZone: "us-east-1b"},
+		},
+		{
+			name:    "no subnet in allowed zones",
+			outs:    outs,
+			zones:   []string{"us-east-1d", "us-east-1e"},
+			wantErr: true,
+		},
+		{
+			name:    "no network",
+			outs:    auto.OutputMap{},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := networkFromOutputs(tc.outs, tc.zones)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("networkFromOutputs() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("networkFromOutputs() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"privatebox/internal/images"
+	"privatebox/internal/providers"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+)
+
+// resizeInstance changes an instance's type: it stops the instance, updates the
+// type in its spec and, if it was running, starts it again and waits until it is ready.
+func resizeInstance(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return fmt.Errorf("usage: privatebox resize <name> <type>")
+	}
+	name, newType := cmd.Args().Get(0), cmd.Args().Get(1)
+
+	mgr, cfg, profileName, provider, err := getStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+	raw, _ := outs[providers.SpecOutput].Value.(string)
+	spec, err := providers.ParseSpec(raw)
+	if err != nil {
+		return err
+	}
+	instanceID, _ := outs["instanceID"].Value.(string)
+	if instanceID == "" {
+		return fmt.Errorf("instance ID not found in stack outputs")
+	}
+
+	info, err := provider.GetInstanceStatus(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance status: %w", err)
+	}
+	if info.InstanceType == newType {
+		return fmt.Errorf("instance '%s' already is a %s", name, newType)
+	}
+
+	current, err := provider.DescribeInstanceType(ctx, info.InstanceType)
+	if err != nil {
+		return err
+	}
+	target, err := provider.DescribeInstanceType(ctx, newType)
+	if err != nil {
+		return err
+	}
+	if err := checkResize(info, current, target); err != nil {
+		return fmt.Errorf("cannot resize instance '%s': %w", name, err)
+	}
+
+	if !cmd.Bool("force") {
+		if err := checkBudget(ctx, cfg, profileName, name, newType); err != nil {
+			return err
+		}
+	}
+
+	// Refuse updates that would replace or delete resources before the instance is
+	// stopped. In-place changes of other resources (e.g. firewall rules) still apply.
+	spec.Type = newType
+	spec, err = previewUpdate(ctx, mgr, cfg, profileName, spec)
+	if err != nil {
+		return err
+	}
+
+	wasRunning := false
+	switch info.State {
+	case "running", "pending":
+		wasRunning = true
+		fmt.Printf("Stopping instance '%s' (%s)...\n", name, instanceID)
+		if err := provider.StopInstance(ctx, instanceID, false); err != nil {
+			return fmt.Errorf("failed to stop instance: %w", err)
+		}
+		fallthrough
+	case "stopping":
+		if _, err := waitForState(ctx, provider, instanceID, "stopped", cmd.Duration("timeout")); err != nil {
+			return err
+		}
+	case "stopped":
+	default:
+		return fmt.Errorf("instance '%s' is %s", name, info.State)
+	}
+
+	fmt.Printf("Changing the type of '%s' from %s to %s...\n", name, info.InstanceType, newType)
+	res, err := mgr.Up(ctx, spec)
+	if err != nil {
+		if !wasRunning {
+			return err
+		}
+		// The type is unchanged; bring the instance back rather than leave it stopped
+		fmt.Printf("Update failed, starting instance '%s' (%s) again as a %s...\n", name, instanceID, info.InstanceType)
+		if startErr := startInstance(ctx, provider, instanceID, cmd.Int("retries")); startErr != nil {
+			return fmt.Errorf("%w\ninstance '%s' was left stopped: %v", err, name, startErr)
+		}
+		return err
+	}
+	if id, _ := res.Outputs["instanceID"].Value.(string); id != instanceID {
+		return fmt.Errorf("instance '%s' was replaced by %s during the update", name, id)
+	}
+	if !wasRunning {
+		fmt.Printf("Instance '%s' is now a %s.\n", name, newType)
+		return nil
+	}
+
+	fmt.Printf("Starting instance '%s' (%s)...\n", name, instanceID)
+	if err := startInstance(ctx, provider, instanceID, cmd.Int("retries")); err != nil {
+		return err
+	}
+	defer refreshSSHConfig(ctx)
+
+	ready, err := waitForReady(ctx, provider, instanceID, outputConnectMode(outs), cmd.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+	updateDNSRecord(ctx, provider, outs, ready)
+	fmt.Printf("Instance '%s' is now a %s and ready.\n", name, newType)
+	return nil
+}
+
+// checkResize reports why an instance can't be changed from the current to the
+// target type. EC2 doesn't change the type of spot or hibernation-enabled instances,
+// and the image stays the same, so the target type must support its architecture.
+func checkResize(info *providers.RuntimeInfo, current, target *providers.InstanceType) error {
+	if info.Lifecycle == "spot" {
+		return fmt.Errorf("the type of a spot instance can't be changed")
+	}
+	if info.Hibernation {
+		return fmt.Errorf("the type of an instance launched with hibernation enabled can't be changed")
+	}
+	arch, err := images.Architecture(current.Architectures)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(target.Architectures, arch) {
+		return fmt.Errorf("instance type %s does not support %s, the architecture of the instance's image", target.Name, arch)
+	}
+	if !slices.Contains(target.Zones, info.AvailabilityZone) {
+		return fmt.Errorf("instance type %s is not offered in %s, the instance's zone", target.Name, info.AvailabilityZone)
+	}
+	return nil
+}
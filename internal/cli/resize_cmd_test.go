@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"privatebox/internal/providers"
+	"strings"
+	"testing"
+)
+
+func TestCheckResize(t *testing.T) {
+	t3 := &providers.InstanceType{Name: "t3.large", Architectures: []string{"x86_64"}, Zones: []string{"us-east-1a", "us-east-1b"}}
+	m7i := &providers.InstanceType{Name: "m7i.large", Architectures: []string{"x86_64"}, Zones: []string{"us-east-1a", "us-east-1b"}}
+	t4g := &providers.InstanceType{Name: "t4g.large", Architectures: []string{"arm64"}, Zones: []string{"us-east-1a", "us-east-1b"}}
+	p5 := &providers.InstanceType{Name: "p5.48xlarge", Architectures: []string{"x86_64"}, Zones: []string{"us-east-1b"}}
+
+	tests := []struct {
+		name    string
+		info    providers.RuntimeInfo
+		target  *providers.InstanceType
+		wantErr string
+	}{
+		{
+			name:   "same architecture and zone",
+			info:   providers.RuntimeInfo{Lifecycle: "on-demand", AvailabilityZone: "us-east-1a"},
+			target: m7i,
+		},
+		{
+			name:    "spot",
+			info:    providers.RuntimeInfo{Lifecycle: "spot", AvailabilityZone: "us-east-1a"},
+			target:  m7i,
+			wantErr: "spot instance",
+		},
+		{
+			name:    "hibernation enabled",
+			info:    providers.RuntimeInfo{Lifecycle: "on-demand", Hibernation: true, AvailabilityZone: "us-east-1a"},
+			target:  m7i,
+			wantErr: "hibernation enabled",
+		},
+		{
+			name:    "other architecture",
+			info:    providers.RuntimeInfo{Lifecycle: "on-demand", AvailabilityZone: "us-east-1a"},
+			target:  t4g,
+			wantErr: "does not support x86_64",
+		},
+		{
+			name:    "not offered in the zone",
+			info:    providers.RuntimeInfo{Lifecycle: "on-demand", AvailabilityZone: "us-east-1a"},
+			target:  p5,
+			wantErr: "not offered in us-east-1a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkResize(&tc.info, t3, tc.target)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkResize() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("checkResize() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
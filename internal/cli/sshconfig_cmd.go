@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"privatebox/internal/config"
+	"privatebox/internal/providers/aws"
+	"privatebox/internal/sshconfig"
+	"sort"
+
+	"github.com/urfave/cli/v3"
+)
+
+// sshConfigFile is the managed ssh config file, relative to the home directory.
+const sshConfigFile = ".ssh/privatebox.conf"
+
+// sshConfigPaths returns the managed ssh config file and the user's ssh config.
+func sshConfigPaths() (managed, user string, err error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, sshConfigFile), filepath.Join(home, ".ssh", "config"), nil
+}
+
+// sshConfigHosts returns the ssh hosts of the instances of all profiles.
+func sshConfigHosts(ctx context.Context) ([]sshconfig.Host, error) {
+	loader, err := config.NewLoader()
+	if err != nil {
+		return nil, err
+	}
+	appCfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(appCfg.Profiles))
+	for name := range appCfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var hosts []sshconfig.Host
+	seen := map[string]bool{}
+	for _, profileName := range names {
+		profile := appCfg.Profiles[profileName]
+		if profile.Provider != providerAWS {
+			continue
+		}
+		instances, err := listProfileInstances(ctx, &profile, profileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping profile '%s': %v\n", profileName, err)
+			continue
+		}
+
+		provider := aws.New(profile)
+		for _, inst := range instances {
+			if seen[inst.Name] {
+				fmt.Fprintf(os.Stderr, "Skipping instance '%s' of profile '%s': the name is used by another profile\n", inst.Name, profileName)
+				continue
+			}
+			seen[inst.Name] = true
+
+			host := sshconfig.Host{Name: inst.Name}
+			if inst.Err == nil {
+				conn := newConnection(inst.Name, inst.Info.ID, inst.Outputs, inst.Info, &profile, provider)
+				host.User = conn.User
+				host.ProxyCommand = conn.ProxyCommand()
+				if conn.KeyPath != "" {
+					host.IdentityFile = expandHome(conn.KeyPath)
+				}
+				// Public IPs are released when the instance stops
+				if conn.SSM() || (conn.Reachable() && (inst.Info.State == "running" || inst.Info.State == "pending")) {
+					host.HostName = conn.Address()
+				}
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+func writeSSHConfig(ctx context.Context, cmd *cli.Command) error {
+	hosts, err := sshConfigHosts(ctx)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("print") {
+		fmt.Print(sshconfig.Render(hosts))
+		return nil
+	}
+
+	managed, user, err := sshConfigPaths()
+	if err != nil {
+		return err
+	}
+	if err := sshconfig.Write(managed, hosts); err != nil {
+		return fmt.Errorf("failed to write %s: %w", managed, err)
+	}
+	fmt.Printf("Wrote %d host(s) to %s. Connect with 'ssh %s<name>'.\n", len(hosts), managed, sshconfig.HostPrefix)
+
+	if !cmd.Bool("install") {
+		fmt.Printf("Add 'Include %s' to the top of %s (or run with --install).\n", managed, user)
+		return nil
+	}
+	changed, err := sshconfig.EnsureInclude(user, managed)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", user, err)
+	}
+	if changed {
+		fmt.Printf("Added 'Include %s' to %s.\n", managed, user)
+	}
+	return nil
+}
+
+// sshConfigManaged reports whether the managed ssh config file exists.
+func sshConfigManaged() bool {
+	managed, _, err := sshConfigPaths()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(managed)
+	return err == nil
+}
+
+// refreshSSHConfig rewrites the managed ssh config after instances changed, if the
+// user has created it with 'privatebox ssh-config'. Failures are only reported.
+func refreshSSHConfig(ctx context.Context) {
+	if !sshConfigManaged() {
+		return
+	}
+	managed, _, err := sshConfigPaths()
+	if err != nil {
+		return
+	}
+
+	hosts, err := sshConfigHosts(ctx)
+	if err == nil {
+		err = sshconfig.Write(managed, hosts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", managed, err)
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"privatebox/internal/userdata"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+func statusInstance(ctx context.Context, cmd *cli.Command) error {
+	name, err := selectInstance(ctx, cmd, "")
+	if err != nil {
+		return err
+	}
+
+	mgr, cfg, _, provider, err := getStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	instanceID, ok := outs["instanceID"].Value.(string)
+	if !ok || instanceID == "" {
+		return fmt.Errorf("instance ID not found in stack outputs")
+	}
+	profileName, _ := outs["profileName"].Value.(string)
+	privateIP, _ := outs["privateIP"].Value.(string)
+	idlePolicy, _ := outs["idleShutdown"].Value.(string)
+	scheduleDesc, _ := outs["schedule"].Value.(string)
+	hostname, _ := outs["hostname"].Value.(string)
+	elasticIP, _ := outs["elasticIP"].Value.(string)
+
+	info, err := provider.GetInstanceStatus(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance status: %w", err)
+	}
+
+	printField("Name", name)
+	printField("Profile", profileName)
+	printField("Instance ID", instanceID)
+	printField("Type", info.InstanceType)
+	printField("Lifecycle", describeLifecycle(ctx, provider, info))
+	printField("Zone", info.AvailabilityZone)
+	printField("State", info.State)
+	if elasticIP != "" {
+		printField("Public IP", elasticIP+" (elastic)")
+	} else {
+		printField("Public IP", info.PublicIP)
+	}
+	printField("Private IP", privateIP)
+	printField("Hostname", hostname)
+	printField("Connect Mode", outputConnectMode(outs))
+	printField("SSH User", outputSSHUser(outs, cfg, provider))
+
+	if scheduleDesc == "" {
+		printField("Schedule", "none")
+	} else {
+		printField("Schedule", scheduleDesc)
+		printField("Next Scheduled", nextTransition(scheduleDesc, time.Now()))
+	}
+
+	if idlePolicy == "" {
+		printField("Idle Shutdown", "disabled")
+		return nil
+	}
+	printField("Idle Shutdown", idlePolicy)
+
+	conn := newConnection(name, instanceID, outs, info, cfg, provider)
+	if info.State == "running" && conn.Reachable() {
+		remaining, err := idleTimeRemaining(ctx, conn)
+		if err != nil {
+			printField("Idle Remaining", fmt.Sprintf("unknown (%v)", err))
+		} else {
+			printField("Idle Remaining", remaining.Round(time.Minute).String())
+		}
+	}
+	return nil
+}
+
+// idleTimeRemaining reads the idle watchdog's state file over SSH.
+func idleTimeRemaining(ctx context.Context, conn *connection) (time.Duration, error) {
+	args := conn.SSHArgs("-o", "BatchMode=yes", "-o", "ConnectTimeout=5")
+	args = append(args, "cat", userdata.IdleStatePath)
+
+	//nolint:gosec // Arguments are derived from the instance's connection details
+	c := exec.CommandContext(ctx, "ssh", args...)
+	c.Env = conn.Env()
+	out, err := c.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read idle state: %w", err)
+	}
+	return userdata.ParseIdleState(string(out), time.Now())
+}
+
+func printField(label, value string) {
+	if value == "" {
+		value = "-"
+	}
+	fmt.Printf("%-16s %s\n", label+":", value)
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"privatebox/internal/userdata"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/urfave/cli/v3"
+)
+
+// syncDebounce is how long sync --watch waits for changes to settle before syncing.
+const syncDebounce = 500 * time.Millisecond
+
+// remotePath is a NAME:PATH argument of cp and sync.
+type remotePath struct {
+	Name string
+	Path string
+}
+
+// parseRemotePath splits a NAME:PATH argument. Arguments without a colon, or with
+// a slash before it (e.g. ./a:b), are local paths.
+func parseRemotePath(arg string) (remotePath, bool) {
+	name, p, ok := strings.Cut(arg, ":")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return remotePath{}, false
+	}
+	return remotePath{Name: name, Path: p}, true
+}
+
+// transferEnds checks that exactly one of src and dst is remote and returns it.
+// upload reports whether the remote end is the destination.
+func transferEnds(src, dst string) (remote remotePath, upload bool, err error) {
+	srcRemote, srcOK := parseRemotePath(src)
+	dstRemote, dstOK := parseRemotePath(dst)
+	switch {
+	case srcOK && dstOK:
+		return remotePath{}, false, fmt.Errorf("copying between instances is not supported")
+	case dstOK:
+		return dstRemote, true, nil
+	case srcOK:
+		return srcRemote, false, nil
+	default:
+		return remotePath{}, false, fmt.Errorf("one of %q and %q must be an instance path (NAME:PATH)", src, dst)
+	}
+}
+
+// connectOptionsFromFlags returns the connect options of commands that accept the
+// --up, --timeout, --retries and --force flags.
+func connectOptionsFromFlags(cmd *cli.Command) connectOptions {
+	return connectOptions{
+		Start:   cmd.Bool("up"),
+		Prompt:  true,
+		Timeout: cmd.Duration("timeout"),
+		Retries: cmd.Int("retries"),
+		Force:   cmd.Bool("force"),
+	}
+}
+
+// Remote returns the scp/rsync form of a path on the instance.
+func (c *connection) Remote(path string) string {
+	return c.Host() + ":" + path
+}
+
+func copyFiles(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return fmt.Errorf("usage: privatebox cp <local> <name>:<path> (or the reverse)")
+	}
+	src, dst := cmd.Args().Get(0), cmd.Args().Get(1)
+	remote, upload, err := transferEnds(src, dst)
+	if err != nil {
+		return err
+	}
+
+	conn, err := resolveConnection(ctx, cmd, remote.Name, connectOptionsFromFlags(cmd))
+	if err != nil {
+		return err
+	}
+
+	args := conn.SSHOptions()
+	recursive := cmd.Bool("recursive")
+	if upload {
+		if info, err := os.Stat(src); err == nil && info.IsDir() {
+			recursive = true
+		}
+	}
+	if recursive {
+		args = append(args, "-r")
+	}
+	if upload {
+		args = append(args, src, conn.Remote(remote.Path))
+	} else {
+		args = append(args, conn.Remote(remote.Path), dst)
+	}
+
+	//nolint:gosec // Arguments are derived from the instance's connection details and the user's paths
+	c := exec.CommandContext(ctx, "scp", args...)
+	c.Env = conn.Env()
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+func syncFiles(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return fmt.Errorf("usage: privatebox sync <dir> <name>:<dir> (or the reverse)")
+	}
+	src, dst := cmd.Args().Get(0), cmd.Args().Get(1)
+	remote, upload, err := transferEnds(src, dst)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("watch") && !upload {
+		return fmt.Errorf("--watch needs a local source directory")
+	}
+
+	conn, err := resolveConnection(ctx, cmd, remote.Name, connectOptionsFromFlags(cmd))
+	if err != nil {
+		return err
+	}
+
+	// Sync the contents of the source directory into the destination directory
+	// (a trailing slash for rsync). An empty remote path is the home directory.
+	if upload {
+		src = withTrailingSlash(src)
+		dst = conn.Remote(remote.Path)
+	} else if remote.Path != "" {
+		src = conn.Remote(withTrailingSlash(remote.Path))
+	} else {
+		src = conn.Remote("")
+	}
+
+	args := []string{"-az", "-e", rsyncShell(conn)}
+	if cmd.Bool("delete") {
+		args = append(args, "--delete")
+	}
+	for _, pattern := range cmd.StringSlice("exclude") {
+		args = append(args, "--exclude", pattern)
+	}
+	args = append(args, src, dst)
+
+	run := func() error {
+		//nolint:gosec // Arguments are derived from the instance's connection details and the user's paths
+		c := exec.CommandContext(ctx, "rsync", args...)
+		c.Env = conn.Env()
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		return c.Run()
+	}
+	if err := run(); err != nil {
+		return fmt.Errorf("rsync failed: %w", err)
+	}
+	if !cmd.Bool("watch") {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Watching %s for changes, press Ctrl-C to stop.\n", cmd.Args().Get(0))
+	return watchDir(ctx, cmd.Args().Get(0), cmd.StringSlice("exclude"), func() {
+		if err := run(); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "rsync failed: %v\n", err)
+			return
+		}
+		fmt.Printf("Synced at %s\n", time.Now().Format("15:04:05"))
+	})
+}
+
+func withTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p
+	}
+	return p + "/"
+}
+
+// rsyncShell returns the remote shell command rsync uses to reach the instance.
+func rsyncShell(conn *connection) string {
+	parts := []string{"ssh"}
+	for _, opt := range conn.SSHOptions() {
+		parts = append(parts, userdata.ShellQuote(opt))
+	}
+	return strings.Join(parts, " ")
+}
+
+// watchDir calls fn whenever files below dir have changed (after they settled),
+// until the context is cancelled. Paths matching an exclude pattern are not watched,
+// and changes to them are ignored.
+func watchDir(ctx context.Context, dir string, exclude []string, fn func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	defer watcher.Close()
+
+	isExcluded := func(p string) bool {
+		rel, err := filepath.Rel(dir, p)
+		return err == nil && rel != "." && excluded(filepath.ToSlash(rel), exclude)
+	}
+
+	// fsnotify is not recursive: every directory is watched on its own
+	addTree := func(root string) error {
+		return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if isExcluded(p) {
+				return filepath.SkipDir
+			}
+			return watcher.Add(p)
+		})
+	}
+	if err := addTree(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isExcluded(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = addTree(event.Name)
+				}
+			}
+			settled = time.After(syncDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Watch error: %v\n", err)
+		case <-settled:
+			settled = nil
+			fn()
+		}
+	}
+}
+
+// excluded reports whether rel (a slash-separated path below the synced directory)
+// or one of its parent directories matches an rsync --exclude pattern. Like rsync,
+// patterns without a slash match any path component and a leading slash anchors the
+// pattern at the synced directory; unlike rsync, a trailing slash is ignored and "**"
+// is not supported.
+func excluded(rel string, patterns []string) bool {
+	parts := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		anchored := strings.HasPrefix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		n := strings.Count(pattern, "/") + 1
+		for i := 0; i+n <= len(parts) && (i == 0 || !anchored); i++ {
+			if ok, _ := path.Match(pattern, strings.Join(parts[i:i+n], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
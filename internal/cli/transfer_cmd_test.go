@@ -0,0 +1,33 @@
+package cli
+
+import "testing"
+
+func TestExcluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		rel      string
+		patterns []string
+		want     bool
+	}{
+		{name: "no patterns", rel: "src/main.go"},
+		{name: "directory name", rel: "node_modules", patterns: []string{"node_modules"}, want: true},
+		{name: "below excluded directory", rel: "web/node_modules/react/index.js", patterns: []string{"node_modules"}, want: true},
+		{name: "trailing slash", rel: "web/node_modules/react", patterns: []string{"node_modules/"}, want: true},
+		{name: "glob on base name", rel: "logs/debug.log", patterns: []string{"*.log"}, want: true},
+		{name: "glob does not cross components", rel: "logs/debug.log.gz", patterns: []string{"*.log"}},
+		{name: "similar name", rel: "node_modules_backup/a", patterns: []string{"node_modules"}},
+		{name: "anchored", rel: "build/out", patterns: []string{"/build"}, want: true},
+		{name: "anchored only at the root", rel: "web/build/out", patterns: []string{"/build"}},
+		{name: "multiple components", rel: "web/dist/cache/x", patterns: []string{"dist/cache"}, want: true},
+		{name: "multiple components in order", rel: "cache/dist/x", patterns: []string{"dist/cache"}},
+		{name: "any pattern", rel: ".git/HEAD", patterns: []string{"node_modules", ".git"}, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := excluded(tc.rel, tc.patterns); got != tc.want {
+				t.Errorf("excluded(%q, %v) = %v, want %v", tc.rel, tc.patterns, got, tc.want)
+			}
+		})
+	}
+}
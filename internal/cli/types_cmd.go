@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"privatebox/internal/images"
+	"privatebox/internal/providers"
+	"privatebox/internal/providers/aws"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v3"
+)
+
+// typeCandidate is an instance type with its on-demand price.
+type typeCandidate struct {
+	providers.InstanceType
+	Price   float64
+	PriceOK bool
+}
+
+// listInstanceTypes shows the instance types of the profile's region matching the
+// flags, cheapest first.
+func listInstanceTypes(ctx context.Context, cmd *cli.Command) error {
+	profile, _, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+	if profile.Provider != providerAWS {
+		return fmt.Errorf("unsupported provider: %s", profile.Provider)
+	}
+	provider := aws.New(*profile)
+
+	arch := ""
+	if raw := cmd.String("arch"); raw != "" {
+		if arch, err = images.ParseArch(raw); err != nil {
+			return err
+		}
+	}
+
+	table, err := loadPriceTable()
+	if err != nil {
+		return err
+	}
+
+	types, err := provider.ListInstanceTypes(ctx, arch)
+	if err != nil {
+		return err
+	}
+
+	minCPU := cmd.Int("min-cpu")
+	minMemMiB := int64(cmd.Float("min-mem") * 1024)
+	maxPrice := cmd.Float("max-price")
+
+	var matches []typeCandidate
+	for _, t := range types {
+		if t.VCPUs < minCPU || t.MemoryMiB < minMemMiB {
+			continue
+		}
+		c := typeCandidate{InstanceType: t}
+		c.Price, c.PriceOK = table.InstanceHourly(profile.Region, t.Name)
+		if maxPrice > 0 && (!c.PriceOK || c.Price > maxPrice) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+
+	// Cheapest first, types without a known price last
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.PriceOK != b.PriceOK {
+			return a.PriceOK
+		}
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		if a.VCPUs != b.VCPUs {
+			return a.VCPUs < b.VCPUs
+		}
+		return a.Name < b.Name
+	})
+
+	total := len(matches)
+	if limit := cmd.Int("limit"); limit > 0 && total > limit {
+		matches = matches[:limit]
+	}
+	if total == 0 {
+		fmt.Println("No matching instance types.")
+		return nil
+	}
+
+	out := tablewriter.NewWriter(os.Stdout)
+	out.SetHeader([]string{"TYPE", "ARCH", "VCPUS", "MEMORY", "PRICE", "ZONES"})
+	out.SetBorder(false)
+	out.SetAutoWrapText(false)
+	for _, c := range matches {
+		price := "unknown"
+		if c.PriceOK {
+			price = fmt.Sprintf("$%.4f/h", c.Price)
+		}
+		out.Append([]string{
+			c.Name,
+			strings.Join(c.Architectures, ","),
+			strconv.Itoa(c.VCPUs),
+			strconv.FormatFloat(float64(c.MemoryMiB)/1024, 'f', -1, 64) + " GiB",
+			price,
+			zoneSuffixes(profile.Region, c.Zones),
+		})
+	}
+	out.Render()
+
+	fmt.Printf("\nShowing %d of %d matching types in %s. On-demand prices as of %s.\n",
+		len(matches), total, profile.Region, table.UpdatedFor(profile.Region))
+	return nil
+}
+
+// zoneSuffixes shortens zones to their letters, e.g. "a,b,d" for us-east-1a, -1b and -1d.
+func zoneSuffixes(region string, zones []string) string {
+	short := make([]string, 0, len(zones))
+	for _, z := range zones {
+		short = append(short, strings.TrimPrefix(z, region))
+	}
+	return strings.Join(short, ",")
+}
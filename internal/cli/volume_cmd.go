@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"privatebox/internal/config"
+	"privatebox/internal/orchestration"
+	"privatebox/internal/providers"
+	"privatebox/internal/providers/aws"
+	"privatebox/internal/userdata"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/urfave/cli/v3"
+)
+
+func resizeVolumes(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+	if cmd.Int("root") == 0 && len(cmd.StringSlice("data")) == 0 {
+		return fmt.Errorf("nothing to resize (use --root and/or --data)")
+	}
+
+	mgr, cfg, profileName, provider, err := getStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	spec, err := storedSpec(ctx, mgr)
+	if err != nil {
+		return err
+	}
+
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+	instanceID, _ := outs["instanceID"].Value.(string)
+	info, err := provider.GetInstanceStatus(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance status: %w", err)
+	}
+
+	if size := cmd.Int("root"); size > 0 {
+		// The spec has no size if the image's default was used; compare with the volume
+		volumes, err := provider.GetVolumes(ctx, instanceID)
+		if err != nil {
+			return fmt.Errorf("failed to get volumes: %w", err)
+		}
+		current, ok := rootVolumeSize(volumes, info.RootDevice)
+		if !ok {
+			return fmt.Errorf("root volume of instance '%s' not found", name)
+		}
+		if size <= current {
+			return fmt.Errorf("root volume is %d GiB, volumes can only grow", current)
+		}
+		root := config.RootVolume{}
+		if spec.RootVolume != nil {
+			root = *spec.RootVolume
+		}
+		root.SizeGiB = size
+		spec.RootVolume = &root
+	}
+
+	for _, arg := range cmd.StringSlice("data") {
+		mountPoint, size, err := parseVolumeSize(arg)
+		if err != nil {
+			return err
+		}
+		if err := growDataVolume(&spec, mountPoint, size); err != nil {
+			return err
+		}
+	}
+
+	if _, err := updateSpec(ctx, mgr, cfg, profileName, spec); err != nil {
+		return err
+	}
+
+	conn := newConnection(name, instanceID, outs, info, cfg, provider)
+	if info.State != "running" || !conn.Reachable() {
+		fmt.Printf("Volumes resized. File systems are grown when '%s' boots next.\n", name)
+		return nil
+	}
+
+	fmt.Println("Growing file systems...")
+	args := conn.SSHArgs("-o", "BatchMode=yes", "-o", "ConnectTimeout=10")
+	args = append(args, "sudo sh -c "+userdata.ShellQuote(userdata.GrowFilesystemsCommand))
+
+	//nolint:gosec // Arguments are derived from the instance's connection details
+	c := exec.CommandContext(ctx, "ssh", args...)
+	c.Env = conn.Env()
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("volumes resized, but growing the file systems failed (they are grown on the next boot): %w", err)
+	}
+
+	fmt.Printf("Volumes of '%s' resized.\n", name)
+	return nil
+}
+
+// parseVolumeSize parses a MOUNT_POINT=GiB argument.
+func parseVolumeSize(arg string) (string, int, error) {
+	mountPoint, rawSize, ok := strings.Cut(arg, "=")
+	size, err := strconv.Atoi(rawSize)
+	if !ok || err != nil || size <= 0 {
+		return "", 0, fmt.Errorf("invalid volume size %q, expected MOUNT_POINT=GiB", arg)
+	}
+	return mountPoint, size, nil
+}
+
+// rootVolumeSize returns the size of the volume attached as rootDevice.
+func rootVolumeSize(volumes []providers.VolumeInfo, rootDevice string) (int, bool) {
+	for _, v := range volumes {
+		if v.Device == rootDevice {
+			return v.SizeGiB, true
+		}
+	}
+	return 0, false
+}
+
+// growDataVolume sets the size of the data volume mounted at mountPoint.
+func growDataVolume(spec *providers.InstanceSpec, mountPoint string, size int) error {
+	for i, v := range spec.DataVolumes {
+		if path.Clean(v.MountPoint) != path.Clean(mountPoint) {
+			continue
+		}
+		if size <= v.SizeGiB {
+			return fmt.Errorf("volume %s is %d GiB, volumes can only grow", v.MountPoint, v.SizeGiB)
+		}
+		spec.DataVolumes[i].SizeGiB = size
+		return nil
+	}
+	return fmt.Errorf("instance '%s' has no data volume mounted at %s", spec.Name, mountPoint)
+}
+
+// volumeStackManager returns the stack manager of a persistent volume.
+func volumeStackManager(cmd *cli.Command, volumeName string) (*orchestration.StackManager, *config.Profile, string, providers.CloudProvider, error) {
+	_, cfg, profileName, provider, err := getStackManager(cmd, volumeName)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	return orchestration.NewVolumeStackManager(cfg, provider, volumeName), cfg, profileName, provider, nil
+}
+
+// ensureVolume returns the outputs of a persistent volume, creating it from the
+// profile's persistent_volume settings if it does not exist yet.
+func ensureVolume(ctx context.Context, mgr *orchestration.StackManager, cfg *config.Profile, profileName string, provider providers.CloudProvider, volumeName string) (auto.OutputMap, error) {
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id, _ := outs["volumeID"].Value.(string); id != "" {
+		return outs, nil
+	}
+
+	if cfg.AWS.PersistentVolume == nil {
+		return nil, fmt.Errorf("volume '%s' does not exist and profile '%s' has no aws.persistent_volume settings", volumeName, profileName)
+	}
+	volume := *cfg.AWS.PersistentVolume
+	if volume.AvailabilityZone == "" {
+		if volume.AvailabilityZone, err = defaultVolumeZone(ctx, cfg, provider); err != nil {
+			return nil, err
+		}
+	}
+	res, err := mgr.UpVolume(ctx, providers.VolumeSpec{
+		Name:        volumeName,
+		ProfileName: profileName,
+		Volume:      volume,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Outputs, nil
+}
+
+// defaultVolumeZone returns the zone for a persistent volume without a configured
+// one: the first zone offering the profile's instance type, so that its instances
+// can be launched next to it.
+func defaultVolumeZone(ctx context.Context, cfg *config.Profile, provider providers.CloudProvider) (string, error) {
+	instanceType := cfg.AWS.InstanceType
+	if instanceType == "" {
+		instanceType = aws.DefaultInstanceType
+	}
+	info, err := provider.DescribeInstanceType(ctx, instanceType)
+	if err != nil {
+		return "", err
+	}
+	if len(info.Zones) == 0 {
+		return "", fmt.Errorf("instance type %s is not offered in any zone of %s", instanceType, cfg.Region)
+	}
+	return info.Zones[0], nil
+}
+
+// resolveHomeVolume prepares a persistent volume for attaching to a new instance.
+func resolveHomeVolume(ctx context.Context, cmd *cli.Command, volumeName string) (*providers.HomeVolume, error) {
+	mgr, cfg, profileName, provider, err := volumeStackManager(cmd, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	outs, err := ensureVolume(ctx, mgr, cfg, profileName, provider, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	home := &providers.HomeVolume{Name: volumeName}
+	home.VolumeID, _ = outs["volumeID"].Value.(string)
+	home.AvailabilityZone, _ = outs["availabilityZone"].Value.(string)
+	home.KeyARN, _ = outs["keyARN"].Value.(string)
+	home.MountPoint, _ = outs["mountPoint"].Value.(string)
+
+	info, err := provider.GetVolume(ctx, home.VolumeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume status: %w", err)
+	}
+	if info.Attached != "" {
+		return nil, fmt.Errorf("volume '%s' is attached to instance %s", volumeName, info.Attached)
+	}
+	return home, nil
+}
+
+func createVolume(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("volume name is required")
+	}
+
+	mgr, cfg, profileName, provider, err := volumeStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+	if _, err := ensureVolume(ctx, mgr, cfg, profileName, provider, name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Volume '%s' is ready. Attach it with 'privatebox create --attach-home %s <instance>'.\n", name, name)
+	return nil
+}
+
+func listVolumes(ctx context.Context, cmd *cli.Command) error {
+	profile, _, err := loadProfile(cmd)
+	if err != nil {
+		return err
+	}
+
+	names, err := orchestration.ListVolumeStacks(profile)
+	if err != nil {
+		return fmt.Errorf("failed to list volumes: %w", err)
+	}
+	if len(names) == 0 {
+		fmt.Println("No volumes found.")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"NAME", "PROFILE", "SIZE", "ZONE", "MOUNT POINT", "STATE", "ATTACHED TO"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+
+	for _, name := range names {
+		mgr, _, _, provider, err := volumeStackManager(cmd, name)
+		if err != nil {
+			return err
+		}
+		outs, err := mgr.GetOutputs(ctx)
+		if err != nil {
+			table.Append([]string{name, "", "", "", "", "Error: " + err.Error(), ""})
+			continue
+		}
+
+		id, _ := outs["volumeID"].Value.(string)
+		profileName, _ := outs["profileName"].Value.(string)
+		zone, _ := outs["availabilityZone"].Value.(string)
+		mountPoint, _ := outs["mountPoint"].Value.(string)
+		if id == "" {
+			table.Append([]string{name, profileName, "", zone, mountPoint, "Provisioning/Error", ""})
+			continue
+		}
+
+		size, state, attached := "", "", ""
+		if info, err := provider.GetVolume(ctx, id); err == nil {
+			size = fmt.Sprintf("%d GiB", info.SizeGiB)
+			state = info.State
+			attached = instanceNameByID(ctx, profile, provider, info.Attached)
+		} else {
+			state = fmt.Sprintf("Error: %v", err)
+		}
+		table.Append([]string{name, profileName, size, zone, mountPoint, state, attached})
+	}
+
+	table.Render()
+	return nil
+}
+
+// instanceNameByID returns the name of the instance with the given ID, or the ID
+// itself if it is not managed by privatebox.
+func instanceNameByID(ctx context.Context, profile *config.Profile, provider providers.CloudProvider, id string) string {
+	if id == "" {
+		return ""
+	}
+	stacks, err := orchestration.ListStacks(profile)
+	if err != nil {
+		return id
+	}
+	for _, name := range stacks {
+		outs, err := orchestration.NewStackManager(profile, provider, name).GetOutputs(ctx)
+		if err != nil {
+			continue
+		}
+		if instanceID, _ := outs["instanceID"].Value.(string); instanceID == id {
+			return name
+		}
+	}
+	return id
+}
+
+func destroyVolume(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("volume name is required")
+	}
+
+	mgr, _, _, provider, err := volumeStackManager(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	outs, err := mgr.GetOutputs(ctx)
+	if err != nil {
+		return err
+	}
+	if id, _ := outs["volumeID"].Value.(string); id != "" {
+		info, err := provider.GetVolume(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get volume status: %w", err)
+		}
+		if info.Attached != "" {
+			return fmt.Errorf("volume '%s' is attached to instance %s, destroy the instance first", name, info.Attached)
+		}
+	}
+
+	if !confirm(fmt.Sprintf("Permanently delete volume '%s' and its data", name)) {
+		return fmt.Errorf("aborted")
+	}
+	if _, err := mgr.Destroy(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Volume '%s' destroyed.\n", name)
+	return nil
+}
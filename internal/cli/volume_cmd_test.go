@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"privatebox/internal/providers"
+	"testing"
+)
+
+func TestRootVolumeSize(t *testing.T) {
+	volumes := []providers.VolumeInfo{
+		{ID: "vol-data", Device: "/dev/sdf", SizeGiB: 500},
+		{ID: "vol-root", Device: "/dev/xvda", SizeGiB: 8},
+		{ID: "vol-home", Device: "/dev/sdp", SizeGiB: 100},
+	}
+
+	tests := []struct {
+		name       string
+		rootDevice string
+		want       int
+		wantOK     bool
+	}{
+		{name: "found", rootDevice: "/dev/xvda", want: 8, wantOK: true},
+		{name: "other device name", rootDevice: "/dev/sda1"},
+		{name: "unknown root device", rootDevice: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := rootVolumeSize(volumes, tc.rootDevice)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("rootVolumeSize(%q) = %d, %v, want %d, %v", tc.rootDevice, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
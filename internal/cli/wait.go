@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWaitTimeout = 10 * time.Minute
+	waitPollInterval   = 5 * time.Second
+	sshPort            = "22"
+)
+
+// spinner renders a simple progress indicator on stderr until stopped. When stderr
+// is not a terminal it prints each new message on its own line instead.
+type spinner struct {
+	mu      sync.Mutex
+	out     io.Writer
+	tty     bool
+	message string
+	start   time.Time
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newSpinner(message string) *spinner {
+	return startSpinner(os.Stderr, isTerminal(os.Stderr), message)
+}
+
+func startSpinner(out io.Writer, tty bool, message string) *spinner {
+	s := &spinner{out: out, tty: tty, message: message, start: time.Now(), done: make(chan struct{})}
+	if !tty {
+		fmt.Fprintln(out, message)
+		return s
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *spinner) run() {
+	defer s.wg.Done()
+	frames := []string{"|", "/", "-", "\\"}
+	ticker := time.NewTicker(150 * time.Millisecond)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		s.mu.Lock()
+		fmt.Fprintf(s.out, "\r\033[K%s %s (%s)", frames[i%len(frames)], s.message, time.Since(s.start).Round(time.Second))
+		s.mu.Unlock()
+
+		select {
+		case <-s.done:
+			fmt.Fprint(s.out, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update changes the message shown next to the spinner.
+func (s *spinner) Update(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.tty && message != s.message {
+		fmt.Fprintln(s.out, message)
+	}
+	s.message = message
+}
+
+// Stop clears the spinner line.
+func (s *spinner) Stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// isTerminal reports whether f is a terminal (character device).
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// waitForState polls the provider until the instance reaches the desired state.
+func waitForState(ctx context.Context, provider providers.CloudProvider, instanceID, state string, timeout time.Duration) (*providers.RuntimeInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sp := newSpinner(fmt.Sprintf("Waiting for %s to be %s", instanceID, state))
+	defer sp.Stop()
+
+	return pollState(ctx, provider, instanceID, state, sp)
+}
+
+// waitForReady waits until the instance is running, has passed its status checks
+// and accepts TCP connections on the SSH port (or, with the SSM connect mode,
+// SSM sessions).
+func waitForReady(ctx context.Context, provider providers.CloudProvider, instanceID, mode string, timeout time.Duration) (*providers.RuntimeInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sp := newSpinner(fmt.Sprintf("Waiting for %s to be running", instanceID))
+	defer sp.Stop()
+
+	info, err := pollState(ctx, provider, instanceID, "running", sp)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.Update(fmt.Sprintf("Waiting for %s status checks", instanceID))
+	if err := poll(ctx, func() (bool, error) {
+		return provider.InstanceReady(ctx, instanceID)
+	}); err != nil {
+		return nil, fmt.Errorf("waiting for status checks: %w", err)
+	}
+
+	if mode == config.ConnectModeSSM {
+		sp.Update(fmt.Sprintf("Waiting for the SSM agent of %s", instanceID))
+		if err := poll(ctx, func() (bool, error) {
+			return provider.SessionReady(ctx, instanceID)
+		}); err != nil {
+			return nil, fmt.Errorf("waiting for ssm agent: %w", err)
+		}
+	} else if info.PublicIP != "" {
+		addr := net.JoinHostPort(info.PublicIP, sshPort)
+		sp.Update(fmt.Sprintf("Waiting for SSH on %s", addr))
+		if err := poll(ctx, func() (bool, error) {
+			return probeTCP(ctx, addr), nil
+		}); err != nil {
+			return nil, fmt.Errorf("waiting for ssh: %w", err)
+		}
+	}
+
+	return info, nil
+}
+
+// pollState polls until the instance reaches the desired state and returns the last runtime info.
+// New instances may not be visible to the API for a moment, which counts as not there yet.
+func pollState(ctx context.Context, provider providers.CloudProvider, instanceID, state string, sp *spinner) (*providers.RuntimeInfo, error) {
+	var info *providers.RuntimeInfo
+	err := poll(ctx, func() (bool, error) {
+		status, err := provider.GetInstanceStatus(ctx, instanceID)
+		if errors.Is(err, providers.ErrInstanceNotFound) {
+			sp.Update(fmt.Sprintf("Waiting for %s to be %s (not visible yet)", instanceID, state))
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		info = status
+		sp.Update(fmt.Sprintf("Waiting for %s to be %s (currently %s)", instanceID, state, status.State))
+		return status.State == state, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for state '%s': %w", state, err)
+	}
+	return info, nil
+}
+
+// poll calls check every waitPollInterval until it returns true, fails or the context expires.
+func poll(ctx context.Context, check func() (bool, error)) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := check()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out")
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeTCP reports whether a TCP connection to addr can be established.
+func probeTCP(ctx context.Context, addr string) bool {
+	dialer := net.Dialer{Timeout: 3 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
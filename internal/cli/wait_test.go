@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSpinnerWithoutTerminal(t *testing.T) {
+	var out bytes.Buffer
+	sp := startSpinner(&out, false, "Waiting for i-1 to be running")
+	sp.Update("Waiting for i-1 to be running (currently pending)")
+	sp.Update("Waiting for i-1 to be running (currently pending)")
+	sp.Update("Waiting for i-1 status checks")
+	sp.Stop()
+
+	want := "Waiting for i-1 to be running\n" +
+		"Waiting for i-1 to be running (currently pending)\n" +
+		"Waiting for i-1 status checks\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDNSTTL is the TTL of instance records. It is short because the record
+// changes whenever the instance is started.
+const DefaultDNSTTL = 60
+
+// Hostname returns the record name of an instance.
+func (d DNS) Hostname(instance string) (string, error) {
+	if d.ZoneID == "" {
+		return "", fmt.Errorf("dns.zone_id is required")
+	}
+	if !strings.Contains(d.Name, "{name}") {
+		return "", fmt.Errorf("dns.name %q must contain {name}", d.Name)
+	}
+	return strings.TrimSuffix(strings.ReplaceAll(d.Name, "{name}", instance), "."), nil
+}
+
+// RecordTTL returns the TTL of instance records in seconds.
+func (d DNS) RecordTTL() int {
+	if d.TTL > 0 {
+		return d.TTL
+	}
+	return DefaultDNSTTL
+}
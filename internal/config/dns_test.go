@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestDNSHostname(t *testing.T) {
+	tests := []struct {
+		dns     DNS
+		want    string
+		wantErr bool
+	}{
+		{dns: DNS{ZoneID: "Z123", Name: "{name}.dev.example.com"}, want: "web.dev.example.com"},
+		{dns: DNS{ZoneID: "Z123", Name: "{name}.dev.example.com."}, want: "web.dev.example.com"},
+		{dns: DNS{ZoneID: "Z123", Name: "box-{name}.example.com"}, want: "box-web.example.com"},
+		{dns: DNS{ZoneID: "Z123", Name: "dev.example.com"}, wantErr: true},
+		{dns: DNS{Name: "{name}.dev.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.dns.Hostname("web")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%+v.Hostname() = %q, %v, want %q (error: %v)", tt.dns, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDNSRecordTTL(t *testing.T) {
+	if got := (DNS{}).RecordTTL(); got != DefaultDNSTTL {
+		t.Errorf("RecordTTL() = %d, want %d", got, DefaultDNSTTL)
+	}
+	if got := (DNS{TTL: 300}).RecordTTL(); got != 300 {
+		t.Errorf("RecordTTL() = %d, want 300", got)
+	}
+}
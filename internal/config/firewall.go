@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MyIP can be used in a rule's CidrBlocks for the caller's public IPv4 address.
+// It is resolved (as a /32) when an instance is created and by "firewall refresh".
+const MyIP = "@myip"
+
+// IngressRulesFor returns the profile's ingress rules, or the defaults when it sets
+// none: SSH from the caller's address, or no rules at all for instances reached
+// through SSM.
+func (p Profile) IngressRulesFor(connectMode string) []SecurityGroupRule {
+	if len(p.AWS.IngressRules) > 0 {
+		return append([]SecurityGroupRule{}, p.AWS.IngressRules...)
+	}
+	if connectMode == ConnectModeSSM {
+		return []SecurityGroupRule{}
+	}
+	return []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{MyIP}},
+	}
+}
+
+// LegacyIngressRulesFor returns the ingress rules of instances created before rules
+// were stored with the instance: the profile's, or SSH from anywhere unless they
+// are reached through SSM.
+func (p Profile) LegacyIngressRulesFor(connectMode string) []SecurityGroupRule {
+	if len(p.AWS.IngressRules) > 0 {
+		return append([]SecurityGroupRule{}, p.AWS.IngressRules...)
+	}
+	if connectMode == ConnectModeSSM {
+		return []SecurityGroupRule{}
+	}
+	return []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{"0.0.0.0/0"}},
+	}
+}
+
+// UsesMyIP reports whether any of the rules refers to MyIP.
+func UsesMyIP(rules []SecurityGroupRule) bool {
+	for _, r := range rules {
+		for _, c := range r.CidrBlocks {
+			if c == MyIP {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExpandMyIP returns a copy of the rules with MyIP replaced by cidr.
+func ExpandMyIP(rules []SecurityGroupRule, cidr string) []SecurityGroupRule {
+	expanded := make([]SecurityGroupRule, 0, len(rules))
+	for _, r := range rules {
+		blocks := make([]string, 0, len(r.CidrBlocks))
+		for _, c := range r.CidrBlocks {
+			if c == MyIP {
+				c = cidr
+			}
+			blocks = append(blocks, c)
+		}
+		r.CidrBlocks = blocks
+		expanded = append(expanded, r)
+	}
+	return expanded
+}
+
+// ParsePorts parses a protocol and port range such as "tcp/22", "udp/60000-61000",
+// "icmp" or "all" into a rule without sources.
+func ParsePorts(s string) (SecurityGroupRule, error) {
+	proto, ports, hasPorts := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	switch proto {
+	case "all", "-1":
+		if hasPorts {
+			return SecurityGroupRule{}, fmt.Errorf("invalid rule %q: %s has no ports", s, proto)
+		}
+		return SecurityGroupRule{Protocol: "-1"}, nil
+	case "icmp":
+		if hasPorts {
+			return SecurityGroupRule{}, fmt.Errorf("invalid rule %q: %s has no ports", s, proto)
+		}
+		return SecurityGroupRule{Protocol: "icmp", FromPort: -1, ToPort: -1}, nil
+	case "tcp", "udp":
+	default:
+		return SecurityGroupRule{}, fmt.Errorf("invalid rule %q: protocol must be tcp, udp, icmp or all", s)
+	}
+	if !hasPorts {
+		return SecurityGroupRule{}, fmt.Errorf("invalid rule %q, expected %s/PORT or %s/FROM-TO", s, proto, proto)
+	}
+
+	rawFrom, rawTo, isRange := strings.Cut(ports, "-")
+	from, err := strconv.Atoi(rawFrom)
+	to := from
+	if err == nil && isRange {
+		to, err = strconv.Atoi(rawTo)
+	}
+	if err != nil || from < 0 || to > 65535 || from > to {
+		return SecurityGroupRule{}, fmt.Errorf("invalid port range %q in rule %q", ports, s)
+	}
+	return SecurityGroupRule{Protocol: proto, FromPort: from, ToPort: to}, nil
+}
+
+// FormatPorts is the inverse of ParsePorts.
+func FormatPorts(r SecurityGroupRule) string {
+	switch {
+	case r.Protocol == "-1":
+		return "all"
+	case r.Protocol == "icmp":
+		return "icmp"
+	case r.FromPort == r.ToPort:
+		return fmt.Sprintf("%s/%d", r.Protocol, r.FromPort)
+	default:
+		return fmt.Sprintf("%s/%d-%d", r.Protocol, r.FromPort, r.ToPort)
+	}
+}
+
+// ParseSource validates a rule source: MyIP, a CIDR block, or a single IPv4 address
+// (returned as a /32).
+func ParseSource(s string) (string, error) {
+	if s == MyIP {
+		return s, nil
+	}
+	if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", fmt.Errorf("invalid source %q, expected an IPv4 address, a CIDR block or %s", s, MyIP)
+	}
+	return ipNet.String(), nil
+}
+
+// AllowRule adds sources to the rule with the same protocol and ports, or appends a
+// new rule. It reports whether the rules changed.
+func AllowRule(rules []SecurityGroupRule, rule SecurityGroupRule) ([]SecurityGroupRule, bool) {
+	for i, r := range rules {
+		if !samePorts(r, rule) {
+			continue
+		}
+		changed := false
+		for _, c := range rule.CidrBlocks {
+			if !slices.Contains(r.CidrBlocks, c) {
+				rules[i].CidrBlocks = append(rules[i].CidrBlocks, c)
+				changed = true
+			}
+		}
+		return rules, changed
+	}
+	return append(rules, rule), true
+}
+
+// RevokeRule removes the given sources from the rule with the same protocol and
+// ports, or the whole rule if rule has no sources. Rules left without sources are
+// removed. It reports whether the rules changed.
+func RevokeRule(rules []SecurityGroupRule, rule SecurityGroupRule) ([]SecurityGroupRule, bool) {
+	result := make([]SecurityGroupRule, 0, len(rules))
+	changed := false
+	for _, r := range rules {
+		if !samePorts(r, rule) {
+			result = append(result, r)
+			continue
+		}
+		if len(rule.CidrBlocks) > 0 {
+			kept := slices.DeleteFunc(slices.Clone(r.CidrBlocks), func(c string) bool {
+				return slices.Contains(rule.CidrBlocks, c)
+			})
+			if len(kept) == len(r.CidrBlocks) {
+				result = append(result, r)
+				continue
+			}
+			if len(kept) > 0 {
+				r.CidrBlocks = kept
+				result = append(result, r)
+			}
+		}
+		changed = true
+	}
+	return result, changed
+}
+
+func samePorts(a, b SecurityGroupRule) bool {
+	return a.Protocol == b.Protocol && a.FromPort == b.FromPort && a.ToPort == b.ToPort
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIngressRulesFor(t *testing.T) {
+	custom := []SecurityGroupRule{{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"0.0.0.0/0"}}}
+
+	tests := []struct {
+		name        string
+		rules       []SecurityGroupRule
+		connectMode string
+		want        []SecurityGroupRule
+	}{
+		{
+			name: "default ssh from my ip",
+			want: []SecurityGroupRule{{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{MyIP}}},
+		},
+		{
+			name:        "ssm needs no rules",
+			connectMode: ConnectModeSSM,
+			want:        []SecurityGroupRule{},
+		},
+		{
+			name:        "configured rules win",
+			rules:       custom,
+			connectMode: ConnectModeSSM,
+			want:        custom,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Profile{AWS: AWSConfig{IngressRules: tc.rules}}
+			got := p.IngressRulesFor(tc.connectMode)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("IngressRulesFor() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLegacyIngressRulesFor(t *testing.T) {
+	custom := []SecurityGroupRule{{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"0.0.0.0/0"}}}
+
+	tests := []struct {
+		name        string
+		rules       []SecurityGroupRule
+		connectMode string
+		want        []SecurityGroupRule
+	}{
+		{
+			name: "ssh from anywhere",
+			want: []SecurityGroupRule{{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{"0.0.0.0/0"}}},
+		},
+		{
+			name:        "ssm needs no rules",
+			connectMode: ConnectModeSSM,
+			want:        []SecurityGroupRule{},
+		},
+		{
+			name:  "configured rules win",
+			rules: custom,
+			want:  custom,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Profile{AWS: AWSConfig{IngressRules: tc.rules}}
+			got := p.LegacyIngressRulesFor(tc.connectMode)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("LegacyIngressRulesFor() = %+v, want %+v", got, tc.want)
+			}
+			if UsesMyIP(got) && tc.rules == nil {
+				t.Errorf("LegacyIngressRulesFor() = %+v, must not use %s", got, MyIP)
+			}
+		})
+	}
+}
+
+func TestExpandMyIP(t *testing.T) {
+	rules := []SecurityGroupRule{
+		{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{MyIP, "10.0.0.0/8"}},
+		{Protocol: "tcp", FromPort: 80, ToPort: 80, CidrBlocks: []string{"0.0.0.0/0"}},
+	}
+
+	if !UsesMyIP(rules) {
+		t.Fatal("UsesMyIP() = false, want true")
+	}
+	if UsesMyIP(rules[1:]) {
+		t.Error("UsesMyIP() = true for rules without @myip")
+	}
+
+	got := ExpandMyIP(rules, "203.0.113.7/32")
+	want := []string{"203.0.113.7/32", "10.0.0.0/8"}
+	if !reflect.DeepEqual(got[0].CidrBlocks, want) {
+		t.Errorf("ExpandMyIP() = %v, want %v", got[0].CidrBlocks, want)
+	}
+	if rules[0].CidrBlocks[0] != MyIP {
+		t.Error("ExpandMyIP() modified its input")
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SecurityGroupRule
+		wantErr bool
+	}{
+		{in: "tcp/8080", want: SecurityGroupRule{Protocol: "tcp", FromPort: 8080, ToPort: 8080}},
+		{in: "UDP/60000-61000", want: SecurityGroupRule{Protocol: "udp", FromPort: 60000, ToPort: 61000}},
+		{in: "icmp", want: SecurityGroupRule{Protocol: "icmp", FromPort: -1, ToPort: -1}},
+		{in: "all", want: SecurityGroupRule{Protocol: "-1"}},
+		{in: "tcp", wantErr: true},
+		{in: "tcp/9000-8000", wantErr: true},
+		{in: "tcp/70000", wantErr: true},
+		{in: "sctp/1", wantErr: true},
+		{in: "icmp/8", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParsePorts(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePorts() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParsePorts() = %+v, want %+v", got, tc.want)
+			}
+			if back, _ := ParsePorts(FormatPorts(got)); !reflect.DeepEqual(back, got) {
+				t.Errorf("FormatPorts() = %q does not parse back", FormatPorts(got))
+			}
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := map[string]string{
+		MyIP:            MyIP,
+		"203.0.113.7":   "203.0.113.7/32",
+		"10.1.2.3/8":    "10.0.0.0/8",
+		"0.0.0.0/0":     "0.0.0.0/0",
+		"example.com":   "",
+		"2001:db8::/32": "",
+	}
+	for in, want := range tests {
+		got, err := ParseSource(in)
+		if want == "" {
+			if err == nil {
+				t.Errorf("ParseSource(%q) = %q, want error", in, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("ParseSource(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestAllowRevokeRule(t *testing.T) {
+	ssh := SecurityGroupRule{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrBlocks: []string{MyIP}}
+	web := SecurityGroupRule{Protocol: "tcp", FromPort: 8080, ToPort: 8080, CidrBlocks: []string{MyIP}}
+
+	rules, changed := AllowRule([]SecurityGroupRule{ssh}, web)
+	if !changed || len(rules) != 2 {
+		t.Fatalf("AllowRule() = %+v, %v, want a new rule", rules, changed)
+	}
+	if _, changed = AllowRule(rules, web); changed {
+		t.Error("AllowRule() changed rules for an existing rule")
+	}
+
+	office := web
+	office.CidrBlocks = []string{"203.0.113.0/24"}
+	rules, changed = AllowRule(rules, office)
+	if !changed || !reflect.DeepEqual(rules[1].CidrBlocks, []string{MyIP, "203.0.113.0/24"}) {
+		t.Fatalf("AllowRule() = %+v, %v, want the source merged", rules, changed)
+	}
+
+	rules, changed = RevokeRule(rules, office)
+	if !changed || !reflect.DeepEqual(rules[1].CidrBlocks, []string{MyIP}) {
+		t.Fatalf("RevokeRule() = %+v, %v, want the source removed", rules, changed)
+	}
+
+	rules, changed = RevokeRule(rules, SecurityGroupRule{Protocol: "tcp", FromPort: 8080, ToPort: 8080})
+	if !changed || !reflect.DeepEqual(rules, []SecurityGroupRule{ssh}) {
+		t.Fatalf("RevokeRule() = %+v, %v, want the rule removed", rules, changed)
+	}
+
+	if _, changed = RevokeRule(rules, office); changed {
+		t.Error("RevokeRule() changed rules for a rule that does not exist")
+	}
+}
@@ -12,6 +12,7 @@ import (
 const (
 	configDirName  = ".config/privatebox"
 	configFileName = "config.yaml"
+	pricesFileName = "prices.json"
 )
 
 // Loader handles reading and writing configuration.
@@ -84,3 +85,8 @@ func (l *Loader) Save(cfg *AppConfig) error {
 func (l *Loader) GetConfigPath() string {
 	return l.configPath
 }
+
+// GetPricesPath returns the path of the refreshed price table.
+func (l *Loader) GetPricesPath() string {
+	return filepath.Join(filepath.Dir(l.configPath), pricesFileName)
+}
@@ -9,23 +9,119 @@ type AppConfig struct {
 
 // Profile represents a specific configuration set.
 type Profile struct {
-	Provider       string            `json:"provider" yaml:"provider"`                       // "aws", "gcp", etc.
-	PulumiBackend  string            `json:"pulumi_backend" yaml:"pulumi_backend"`           // "file://~/.privatebox/state" or s3/url
-	Region         string            `json:"region" yaml:"region"`                           // Global default region
-	SSHPublicKey   string            `json:"ssh_public_key_path" yaml:"ssh_public_key_path"` // Path to public key for instances
-	ConnectCommand string            `json:"connect_command" yaml:"connect_command"`         // Command template to connect (e.g. "ssh {user}@{ip}", "mosh ...")
-	UserData       string            `json:"user_data,omitempty" yaml:"user_data,omitempty"` // Default user-data script for this profile
-	Env            map[string]string `json:"env,omitempty" yaml:"env,omitempty"`             // Extra environment variables
-	AWS            AWSConfig         `json:"aws,omitempty" yaml:"aws,omitempty"`             // AWS specific config
+	Provider       string            `json:"provider" yaml:"provider"`                               // "aws", "gcp", etc.
+	PulumiBackend  string            `json:"pulumi_backend" yaml:"pulumi_backend"`                   // "file://~/.privatebox/state" or s3/url
+	Region         string            `json:"region" yaml:"region"`                                   // Global default region
+	SSHPublicKey   string            `json:"ssh_public_key_path" yaml:"ssh_public_key_path"`         // Path to public key for instances
+	ConnectCommand string            `json:"connect_command" yaml:"connect_command"`                 // Command template to connect (e.g. "ssh {user}@{ip}", "mosh ...")
+	ConnectMode    string            `json:"connect_mode,omitempty" yaml:"connect_mode,omitempty"`   // "ssh" (default) or "ssm": private instances reached through Session Manager
+	SSHUser        string            `json:"ssh_user,omitempty" yaml:"ssh_user,omitempty"`           // Login user of new instances, default: detected from the image
+	Image          string            `json:"image,omitempty" yaml:"image,omitempty"`                 // Image alias, e.g. "ubuntu-24.04" (see "privatebox images"); aws.ami takes precedence
+	UserData       string            `json:"user_data,omitempty" yaml:"user_data,omitempty"`         // Default user-data script for this profile
+	Env            map[string]string `json:"env,omitempty" yaml:"env,omitempty"`                     // Extra environment variables
+	Tags           map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`                   // Tags of new instances, e.g. {team: data}; matched by "exec --selector"
+	IdleShutdown   *IdleShutdown     `json:"idle_shutdown,omitempty" yaml:"idle_shutdown,omitempty"` // Stop instances automatically when idle
+	Schedule       string            `json:"schedule,omitempty" yaml:"schedule,omitempty"`           // Default start/stop window (e.g. "weekdays 08:00-19:00 America/New_York")
+	Budget         *Budget           `json:"budget,omitempty" yaml:"budget,omitempty"`               // Limits enforced before creating/starting instances
+	DNS            *DNS              `json:"dns,omitempty" yaml:"dns,omitempty"`                     // Stable DNS names for instances
+	AWS            AWSConfig         `json:"aws,omitempty" yaml:"aws,omitempty"`                     // AWS specific config
+}
+
+// Connect modes of a profile.
+const (
+	ConnectModeSSH = "ssh" // SSH to the instance's public IP
+	ConnectModeSSM = "ssm" // SSH tunneled through SSM Session Manager; no public IP, no open port
+)
+
+// IdleShutdown configures the on-instance watchdog that stops idle instances.
+// An instance is idle while it has no SSH/SSM sessions and its CPU usage is below the threshold.
+type IdleShutdown struct {
+	After        string `json:"after" yaml:"after"`                                     // e.g. "45m"
+	CPUThreshold int    `json:"cpu_threshold,omitempty" yaml:"cpu_threshold,omitempty"` // percent, default: 10
 }
 
 // AWSConfig holds AWS-specific settings.
 type AWSConfig struct {
-	Profile      string              `json:"profile" yaml:"profile"`
-	InstanceType string              `json:"instance_type" yaml:"instance_type"` // default: t3.micro
-	AMI          string              `json:"ami" yaml:"ami"`                     // optional override
-	IngressRules []SecurityGroupRule `json:"ingress_rules,omitempty" yaml:"ingress_rules,omitempty"`
-	EgressRules  []SecurityGroupRule `json:"egress_rules,omitempty" yaml:"egress_rules,omitempty"`
+	Profile           string              `json:"profile" yaml:"profile"`
+	InstanceType      string              `json:"instance_type" yaml:"instance_type"`                 // default: t3.micro
+	AMI               string              `json:"ami" yaml:"ami"`                                     // optional override
+	Hibernation       bool                `json:"hibernation,omitempty" yaml:"hibernation,omitempty"` // must be enabled at launch to use "down --hibernate"
+	Spot              *SpotConfig         `json:"spot,omitempty" yaml:"spot,omitempty"`
+	RootVolume        *RootVolume         `json:"root_volume,omitempty" yaml:"root_volume,omitempty"`
+	DataVolumes       []DataVolume        `json:"data_volumes,omitempty" yaml:"data_volumes,omitempty"`               // formatted (ext4) and mounted at boot
+	PersistentVolume  *PersistentVolume   `json:"persistent_volume,omitempty" yaml:"persistent_volume,omitempty"`     // used by "create --attach-home"
+	VPCID             string              `json:"vpc_id,omitempty" yaml:"vpc_id,omitempty"`                           // default: the region's default VPC
+	SubnetID          string              `json:"subnet_id,omitempty" yaml:"subnet_id,omitempty"`                     // takes precedence over vpc_id and subnet_tags
+	SubnetTags        map[string]string   `json:"subnet_tags,omitempty" yaml:"subnet_tags,omitempty"`                 // select a subnet by tags, e.g. {Tier: private}
+	AssociatePublicIP *bool               `json:"associate_public_ip,omitempty" yaml:"associate_public_ip,omitempty"` // default: true, false for connect_mode ssm in the dedicated network
+	ElasticIP         bool                `json:"elastic_ip,omitempty" yaml:"elastic_ip,omitempty"`                   // fixed public address that survives stop/start
+	CreateVPC         bool                `json:"create_vpc,omitempty" yaml:"create_vpc,omitempty"`                   // create a dedicated VPC for the profile
+	VPCCIDR           string              `json:"vpc_cidr,omitempty" yaml:"vpc_cidr,omitempty"`                       // CIDR of the dedicated VPC, default: 10.42.0.0/16
+	IngressRules      []SecurityGroupRule `json:"ingress_rules,omitempty" yaml:"ingress_rules,omitempty"`
+	EgressRules       []SecurityGroupRule `json:"egress_rules,omitempty" yaml:"egress_rules,omitempty"`
+}
+
+// DNS gives every instance a record in a Route 53 hosted zone that follows its public IP.
+type DNS struct {
+	ZoneID string `json:"zone_id" yaml:"zone_id"`             // Route 53 hosted zone ID
+	Name   string `json:"name" yaml:"name"`                   // Record name pattern, e.g. "{name}.dev.example.com"
+	TTL    int    `json:"ttl,omitempty" yaml:"ttl,omitempty"` // seconds, default: 60
+}
+
+// Budget limits what instances of a profile may cost. Zero values mean "no limit".
+type Budget struct {
+	MaxRunning      int      `json:"max_running,omitempty" yaml:"max_running,omitempty"`           // Max concurrently running instances
+	MaxHourly       float64  `json:"max_hourly,omitempty" yaml:"max_hourly,omitempty"`             // Max combined compute spend in USD/hour
+	AllowedFamilies []string `json:"allowed_families,omitempty" yaml:"allowed_families,omitempty"` // Instance families (globs allowed), e.g. ["t3", "t4g", "m7*"]
+}
+
+// SpotConfig requests a persistent spot instance instead of an on-demand one.
+type SpotConfig struct {
+	Enabled              bool   `json:"enabled" yaml:"enabled"`
+	MaxPrice             string `json:"max_price,omitempty" yaml:"max_price,omitempty"`                         // USD/hour, default: on-demand price
+	InterruptionBehavior string `json:"interruption_behavior,omitempty" yaml:"interruption_behavior,omitempty"` // "stop" (default) or "hibernate"
+}
+
+// SpotInterruptHibernate is the spot interruption behavior that hibernates the instance.
+const SpotInterruptHibernate = "hibernate"
+
+// HibernationEnabled reports whether instances must be launched with hibernation
+// support, either explicitly or because spot interruptions hibernate them.
+func (p Profile) HibernationEnabled() bool {
+	if p.AWS.Hibernation {
+		return true
+	}
+	spot := p.AWS.Spot
+	return spot != nil && spot.Enabled && spot.InterruptionBehavior == SpotInterruptHibernate
+}
+
+// RootVolume configures the instance's root volume. Zero values keep the defaults
+// (gp3, size of the image).
+type RootVolume struct {
+	SizeGiB    int    `json:"size,omitempty" yaml:"size,omitempty"`             // GiB
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"`             // default: gp3
+	IOPS       int    `json:"iops,omitempty" yaml:"iops,omitempty"`             // gp3, io1, io2 only
+	Throughput int    `json:"throughput,omitempty" yaml:"throughput,omitempty"` // MiB/s, gp3 only
+}
+
+// DataVolume is an additional EBS volume that is formatted and mounted on the instance.
+type DataVolume struct {
+	MountPoint          string `json:"mount_point" yaml:"mount_point"` // e.g. "/data"
+	SizeGiB             int    `json:"size" yaml:"size"`               // GiB
+	Type                string `json:"type,omitempty" yaml:"type,omitempty"`
+	IOPS                int    `json:"iops,omitempty" yaml:"iops,omitempty"`
+	Throughput          int    `json:"throughput,omitempty" yaml:"throughput,omitempty"`
+	DeleteOnTermination *bool  `json:"delete_on_termination,omitempty" yaml:"delete_on_termination,omitempty"` // default: true; false keeps the volume on destroy
+}
+
+// PersistentVolume configures volumes that outlive instances ("create --attach-home").
+type PersistentVolume struct {
+	SizeGiB          int    `json:"size" yaml:"size"` // GiB
+	Type             string `json:"type,omitempty" yaml:"type,omitempty"`
+	IOPS             int    `json:"iops,omitempty" yaml:"iops,omitempty"`
+	Throughput       int    `json:"throughput,omitempty" yaml:"throughput,omitempty"`
+	MountPoint       string `json:"mount_point,omitempty" yaml:"mount_point,omitempty"`             // default: /home
+	AvailabilityZone string `json:"availability_zone,omitempty" yaml:"availability_zone,omitempty"` // default: first zone offering the instance type; instances are placed here
 }
 
 // SecurityGroupRule defines a firewall rule.
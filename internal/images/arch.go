@@ -0,0 +1,70 @@
+package images
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// defaultInstanceTypes are the instance types used for an architecture when
+// nothing else is known.
+var defaultInstanceTypes = map[string]string{
+	ArchX86_64: "t3.micro",
+	ArchARM64:  "t4g.micro",
+}
+
+// counterpartFamilies maps instance families to a family of the same class on the
+// other architecture.
+var counterpartFamilies = map[string]string{
+	// x86_64 to Graviton
+	"t2": "t4g", "t3": "t4g", "t3a": "t4g",
+	"m5": "m7g", "m5a": "m7g", "m6i": "m7g", "m6a": "m7g", "m7i": "m7g", "m7a": "m7g",
+	"c5": "c7g", "c5a": "c7g", "c6i": "c7g", "c6a": "c7g", "c7i": "c7g", "c7a": "c7g",
+	"r5": "r7g", "r5a": "r7g", "r6i": "r7g", "r6a": "r7g", "r7i": "r7g", "r7a": "r7g",
+	// Graviton to x86_64
+	"t4g": "t3",
+	"m6g": "m6i", "m7g": "m7i", "m8g": "m7i",
+	"c6g": "c6i", "c7g": "c7i", "c8g": "c7i",
+	"r6g": "r6i", "r7g": "r7i", "r8g": "r7i",
+}
+
+// ParseArch normalizes an architecture name, accepting the Debian/Linux spellings
+// (amd64, aarch64) too.
+func ParseArch(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case ArchX86_64, "amd64", "x64":
+		return ArchX86_64, nil
+	case ArchARM64, "aarch64", "graviton":
+		return ArchARM64, nil
+	default:
+		return "", fmt.Errorf("unknown architecture %q (expected %s or %s)", s, ArchX86_64, ArchARM64)
+	}
+}
+
+// Architecture picks the architecture to run an instance type with from those it
+// supports, preferring x86_64.
+func Architecture(supported []string) (string, error) {
+	for _, arch := range []string{ArchX86_64, ArchARM64} {
+		if slices.Contains(supported, arch) {
+			return arch, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported architectures %v", supported)
+}
+
+// InstanceTypeFor returns an instance type for arch that resembles instanceType:
+// the same size in the counterpart family (e.g. t3.large -> t4g.large), or the
+// architecture's default type.
+func InstanceTypeFor(instanceType, arch string) string {
+	family, size, ok := strings.Cut(instanceType, ".")
+	if counterpart, known := counterpartFamilies[family]; ok && known && isArch(counterpart, arch) {
+		return counterpart + "." + size
+	}
+	return defaultInstanceTypes[arch]
+}
+
+// isArch reports whether a family of counterpartFamilies runs on arch.
+func isArch(family, arch string) bool {
+	graviton := strings.HasSuffix(family, "g")
+	return graviton == (arch == ArchARM64)
+}
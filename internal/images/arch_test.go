@@ -0,0 +1,51 @@
+package images
+
+import "testing"
+
+func TestParseArch(t *testing.T) {
+	for in, want := range map[string]string{"x86_64": ArchX86_64, "amd64": ArchX86_64, "arm64": ArchARM64, "AArch64": ArchARM64} {
+		if got, err := ParseArch(in); err != nil || got != want {
+			t.Errorf("ParseArch(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseArch("riscv64"); err == nil {
+		t.Error("ParseArch(riscv64) should fail")
+	}
+}
+
+func TestArchitecture(t *testing.T) {
+	tests := []struct {
+		supported []string
+		want      string
+		wantErr   bool
+	}{
+		{supported: []string{"i386", "x86_64"}, want: ArchX86_64},
+		{supported: []string{"arm64"}, want: ArchARM64},
+		{supported: []string{"x86_64_mac"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Architecture(tt.supported)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Architecture(%v) = %q, %v, want %q (error: %v)", tt.supported, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestInstanceTypeFor(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		arch         string
+		want         string
+	}{
+		{instanceType: "t3.large", arch: ArchARM64, want: "t4g.large"},
+		{instanceType: "c6i.2xlarge", arch: ArchARM64, want: "c7g.2xlarge"},
+		{instanceType: "m7g.xlarge", arch: ArchX86_64, want: "m7i.xlarge"},
+		{instanceType: "x2iedn.xlarge", arch: ArchARM64, want: "t4g.micro"},
+		{instanceType: "", arch: ArchX86_64, want: "t3.micro"},
+	}
+	for _, tt := range tests {
+		if got := InstanceTypeFor(tt.instanceType, tt.arch); got != tt.want {
+			t.Errorf("InstanceTypeFor(%q, %q) = %q, want %q", tt.instanceType, tt.arch, got, tt.want)
+		}
+	}
+}
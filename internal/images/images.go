@@ -0,0 +1,165 @@
+// Package images knows the operating system images and CPU architectures instances
+// are launched with.
+package images
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSSHUser is used for images whose operating system is unknown.
+const DefaultSSHUser = "ubuntu"
+
+// Owner account IDs of official images.
+const (
+	OwnerCanonical = "099720109477"
+	OwnerDebian    = "136693071363"
+	OwnerAmazon    = "137112412989"
+	OwnerRedHat    = "309956199498"
+	OwnerFedora    = "125523088429" // Fedora and CentOS Stream
+	OwnerRocky     = "792107900819"
+	OwnerAlma      = "764336703387"
+)
+
+// osUsers maps operating systems, as found in image names, to their login users.
+// Earlier entries win, e.g. "centos" before "rocky" for CentOS images built from Rocky.
+var osUsers = []struct {
+	name string
+	user string
+}{
+	{"ubuntu", "ubuntu"},
+	{"debian", "admin"},
+	{"amzn", "ec2-user"},
+	{"al2023", "ec2-user"},
+	{"amazon linux", "ec2-user"},
+	{"rhel", "ec2-user"},
+	{"red hat", "ec2-user"},
+	{"fedora", "fedora"},
+	{"centos", "centos"},
+	{"rocky", "rocky"},
+	{"almalinux", "ec2-user"},
+	{"suse", "ec2-user"},
+	{"freebsd", "ec2-user"},
+	{"bitnami", "bitnami"},
+}
+
+// ownerUsers maps the owners of official images to their login users.
+var ownerUsers = map[string]string{
+	OwnerCanonical: "ubuntu",
+	OwnerDebian:    "admin",
+	OwnerAmazon:    "ec2-user",
+	OwnerRedHat:    "ec2-user",
+	OwnerFedora:    "fedora",
+	OwnerRocky:     "rocky",
+	OwnerAlma:      "ec2-user",
+}
+
+// SSHUser returns the login user of an image, detected from its name, owner and
+// platform details (e.g. "Red Hat Enterprise Linux"). ok is false if the
+// operating system is unknown; DefaultSSHUser is returned then.
+func SSHUser(name, ownerID, platform string) (user string, ok bool) {
+	for _, s := range []string{name, platform} {
+		s = strings.ToLower(s)
+		for _, entry := range osUsers {
+			if strings.Contains(s, entry.name) {
+				return entry.user, true
+			}
+		}
+	}
+	if user, ok := ownerUsers[ownerID]; ok {
+		return user, true
+	}
+	return DefaultSSHUser, false
+}
+
+// Architectures, as named by EC2.
+const (
+	ArchX86_64 = "x86_64"
+	ArchARM64  = "arm64"
+)
+
+// DefaultImage is the alias of the image used when a profile sets none.
+const DefaultImage = "ubuntu-22.04"
+
+// Entry describes how to find the latest release of an image.
+type Entry struct {
+	Description string
+	Owner       string            // Account ID publishing the image
+	Names       map[string]string // Name filter (wildcards allowed) per architecture
+}
+
+// Catalog maps image aliases to their entries.
+var Catalog = map[string]Entry{
+	"ubuntu-22.04": {
+		Description: "Ubuntu 22.04 LTS",
+		Owner:       OwnerCanonical,
+		Names: map[string]string{
+			ArchX86_64: "ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*",
+			ArchARM64:  "ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-arm64-server-*",
+		},
+	},
+	"ubuntu-24.04": {
+		Description: "Ubuntu 24.04 LTS",
+		Owner:       OwnerCanonical,
+		Names: map[string]string{
+			ArchX86_64: "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-*",
+			ArchARM64:  "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-arm64-server-*",
+		},
+	},
+	"debian-12": {
+		Description: "Debian 12 (bookworm)",
+		Owner:       OwnerDebian,
+		Names: map[string]string{
+			ArchX86_64: "debian-12-amd64-*",
+			ArchARM64:  "debian-12-arm64-*",
+		},
+	},
+	"al2023": {
+		Description: "Amazon Linux 2023",
+		Owner:       OwnerAmazon,
+		Names: map[string]string{
+			ArchX86_64: "al2023-ami-2023.*-x86_64",
+			ArchARM64:  "al2023-ami-2023.*-arm64",
+		},
+	},
+	"rhel-9": {
+		Description: "Red Hat Enterprise Linux 9",
+		Owner:       OwnerRedHat,
+		Names: map[string]string{
+			ArchX86_64: "RHEL-9.*_HVM-*-x86_64-*",
+			ArchARM64:  "RHEL-9.*_HVM-*-arm64-*",
+		},
+	},
+	"rocky-9": {
+		Description: "Rocky Linux 9",
+		Owner:       OwnerRocky,
+		Names: map[string]string{
+			ArchX86_64: "Rocky-9-EC2-Base-9.*.x86_64",
+			ArchARM64:  "Rocky-9-EC2-Base-9.*.aarch64",
+		},
+	},
+}
+
+// Aliases returns the aliases of the catalog, sorted.
+func Aliases() []string {
+	aliases := make([]string, 0, len(Catalog))
+	for alias := range Catalog {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Lookup returns the name filter and owner of an image alias for an architecture.
+func Lookup(alias, arch string) (name, owner string, err error) {
+	entry, ok := Catalog[alias]
+	if !ok {
+		return "", "", fmt.Errorf("unknown image %q (known images: %s)", alias, strings.Join(Aliases(), ", "))
+	}
+	name, ok = entry.Names[arch]
+	if !ok {
+		return "", "", fmt.Errorf("image %q is not available for %s", alias, arch)
+	}
+	return name, entry.Owner, nil
+}
@@ -0,0 +1,59 @@
+package images
+
+import "testing"
+
+func TestSSHUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		owner    string
+		platform string
+		want     string
+		wantOK   bool
+	}{
+		{name: "Ubuntu", image: "ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-20240126", owner: OwnerCanonical, platform: "Linux/UNIX", want: "ubuntu", wantOK: true},
+		{name: "Debian", image: "debian-12-amd64-20240102-1614", owner: OwnerDebian, platform: "Linux/UNIX", want: "admin", wantOK: true},
+		{name: "Amazon Linux 2023", image: "al2023-ami-2023.3.20240122.0-kernel-6.1-x86_64", owner: OwnerAmazon, platform: "Linux/UNIX", want: "ec2-user", wantOK: true},
+		{name: "Amazon Linux 2", image: "amzn2-ami-kernel-5.10-hvm-2.0.20240124.0-x86_64-gp2", owner: OwnerAmazon, platform: "Linux/UNIX", want: "ec2-user", wantOK: true},
+		{name: "RHEL by platform", image: "RHEL-9.3.0_HVM-20240117-x86_64-49-Hourly2-GP3", owner: OwnerRedHat, platform: "Red Hat Enterprise Linux", want: "ec2-user", wantOK: true},
+		{name: "Fedora", image: "Fedora-Cloud-Base-39-1.5.x86_64-hvm-us-east-1-gp3-0", owner: OwnerFedora, platform: "Linux/UNIX", want: "fedora", wantOK: true},
+		{name: "Rocky", image: "Rocky-9-EC2-Base-9.3-20231113.0.x86_64", owner: OwnerRocky, platform: "Linux/UNIX", want: "rocky", wantOK: true},
+		{name: "Custom image of known owner", image: "team-golden-image-42", owner: OwnerDebian, platform: "Linux/UNIX", want: "admin", wantOK: true},
+		{name: "Unknown", image: "team-golden-image-42", owner: "123456789012", platform: "Linux/UNIX", want: DefaultSSHUser, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SSHUser(tt.image, tt.owner, tt.platform)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("SSHUser() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	name, owner, err := Lookup("debian-12", ArchARM64)
+	if err != nil || name != "debian-12-arm64-*" || owner != OwnerDebian {
+		t.Errorf("Lookup() = %q, %q, %v", name, owner, err)
+	}
+	if _, _, err := Lookup("windows-2022", ArchX86_64); err == nil {
+		t.Error("Lookup() of an unknown alias should fail")
+	}
+	if _, _, err := Lookup("al2023", "i386"); err == nil {
+		t.Error("Lookup() of an unknown architecture should fail")
+	}
+	if _, ok := Catalog[DefaultImage]; !ok {
+		t.Errorf("DefaultImage %q is not in the catalog", DefaultImage)
+	}
+}
+
+// Every image of the catalog must have a detectable SSH user.
+func TestCatalogSSHUsers(t *testing.T) {
+	for alias, entry := range Catalog {
+		for arch, name := range entry.Names {
+			if _, ok := SSHUser(name, "", ""); !ok {
+				t.Errorf("SSH user of %s (%s) is not detected from %q", alias, arch, name)
+			}
+		}
+	}
+}
@@ -13,12 +13,29 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/auto"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	instanceProject = "privatebox"
+	volumeProject   = "privatebox-volume"
+	networkProject  = "privatebox-network"
+)
+
+// VolumesDir and NetworksDir are the directories of a file backend that hold persistent
+// volume and network stacks. They are hidden so that ListStacks does not report them
+// as instances.
+const (
+	VolumesDir  = ".volumes"
+	NetworksDir = ".networks"
 )
 
 // StackManager handles the lifecycle of a Pulumi stack.
 type StackManager struct {
 	stackName string
 	project   string
+	stateDir  string // Parent directory of the stack's state in a file backend ("" for instances)
 	cfg       *config.Profile
 	provider  providers.CloudProvider
 }
@@ -27,7 +44,30 @@ type StackManager struct {
 func NewStackManager(cfg *config.Profile, provider providers.CloudProvider, instanceName string) *StackManager {
 	return &StackManager{
 		stackName: instanceName,
-		project:   "privatebox",
+		project:   instanceProject,
+		cfg:       cfg,
+		provider:  provider,
+	}
+}
+
+// NewVolumeStackManager creates a stack manager for a persistent volume, which lives
+// in its own stack so that it survives the instances it is attached to.
+func NewVolumeStackManager(cfg *config.Profile, provider providers.CloudProvider, volumeName string) *StackManager {
+	return &StackManager{
+		stackName: volumeName,
+		project:   volumeProject,
+		stateDir:  VolumesDir,
+		cfg:       cfg,
+		provider:  provider,
+	}
+}
+
+// NewNetworkStackManager creates a stack manager for the dedicated network of a profile.
+func NewNetworkStackManager(cfg *config.Profile, provider providers.CloudProvider, profileName string) *StackManager {
+	return &StackManager{
+		stackName: profileName,
+		project:   networkProject,
+		stateDir:  NetworksDir,
 		cfg:       cfg,
 		provider:  provider,
 	}
@@ -43,6 +83,9 @@ func (s *StackManager) getEnv() map[string]string {
 		if !strings.HasSuffix(backend, "/") {
 			backend += "/"
 		}
+		if s.stateDir != "" {
+			backend += s.stateDir + "/"
+		}
 		backend += s.stackName
 	}
 
@@ -60,8 +103,21 @@ func (s *StackManager) getEnv() map[string]string {
 	return env
 }
 
+// stateProgram returns a program for operations that only read or delete the
+// stack's state; the automation API requires one even though it is not run.
+func (s *StackManager) stateProgram() pulumi.RunFunc {
+	switch s.project {
+	case volumeProject:
+		return s.provider.GetVolumeProgram(providers.VolumeSpec{Name: s.stackName})
+	case networkProject:
+		return s.provider.GetNetworkProgram(providers.NetworkSpec{Name: s.stackName})
+	default:
+		return s.provider.GetPulumiProgram(providers.InstanceSpec{Name: s.stackName})
+	}
+}
+
 // getStack initializes the automation API stack.
-func (s *StackManager) getStack(ctx context.Context, spec providers.InstanceSpec) (auto.Stack, error) {
+func (s *StackManager) getStack(ctx context.Context, program pulumi.RunFunc) (auto.Stack, error) {
 	// Ensure the workdir exists for local state if needed
 	// Pulumi automation API handles workspace setup, but we want to control the backend
 	// The backend URL is set via environment variable PULUMI_BACKEND_URL or project settings.
@@ -69,9 +125,6 @@ func (s *StackManager) getStack(ctx context.Context, spec providers.InstanceSpec
 
 	env := s.getEnv()
 
-	// Prepare the program
-	program := s.provider.GetPulumiProgram(spec)
-
 	// Create or select the stack
 	// We use an inline program
 	stack, err := auto.UpsertStackInlineSource(ctx, s.stackName, s.project, program, auto.EnvVars(env))
@@ -90,13 +143,28 @@ func (s *StackManager) getStack(ctx context.Context, spec providers.InstanceSpec
 
 // Up provisions the instance.
 func (s *StackManager) Up(ctx context.Context, spec providers.InstanceSpec) (auto.UpResult, error) {
-	stack, err := s.getStack(ctx, spec)
+	fmt.Printf("Provisioning instance '%s'...\n", s.stackName)
+	return s.up(ctx, s.provider.GetPulumiProgram(spec))
+}
+
+// UpVolume provisions the persistent volume.
+func (s *StackManager) UpVolume(ctx context.Context, spec providers.VolumeSpec) (auto.UpResult, error) {
+	fmt.Printf("Provisioning volume '%s'...\n", s.stackName)
+	return s.up(ctx, s.provider.GetVolumeProgram(spec))
+}
+
+// UpNetwork provisions the profile's dedicated network.
+func (s *StackManager) UpNetwork(ctx context.Context, spec providers.NetworkSpec) (auto.UpResult, error) {
+	fmt.Printf("Provisioning network for profile '%s'...\n", s.stackName)
+	return s.up(ctx, s.provider.GetNetworkProgram(spec))
+}
+
+func (s *StackManager) up(ctx context.Context, program pulumi.RunFunc) (auto.UpResult, error) {
+	stack, err := s.getStack(ctx, program)
 	if err != nil {
 		return auto.UpResult{}, err
 	}
 
-	fmt.Printf("Provisioning instance '%s'...\n", s.stackName)
-
 	// Run up
 	// We stream stdout to the console so the user sees progress
 	res, err := stack.Up(ctx, optup.ProgressStreams(os.Stdout))
@@ -107,6 +175,26 @@ func (s *StackManager) Up(ctx context.Context, spec providers.InstanceSpec) (aut
 	return res, nil
 }
 
+// Preview computes the changes Up would make to the instance, without making them.
+func (s *StackManager) Preview(ctx context.Context, spec providers.InstanceSpec) (auto.PreviewResult, error) {
+	stack, err := s.getStack(ctx, s.provider.GetPulumiProgram(spec))
+	if err != nil {
+		return auto.PreviewResult{}, err
+	}
+
+	res, err := stack.Preview(ctx)
+	if err != nil {
+		return auto.PreviewResult{}, fmt.Errorf("failed to preview stack: %w", err)
+	}
+	return res, nil
+}
+
+// DestructiveChanges returns the number of resources a preview would replace or delete.
+func DestructiveChanges(summary map[apitype.OpType]int) int {
+	return summary[apitype.OpReplace] + summary[apitype.OpCreateReplacement] +
+		summary[apitype.OpDeleteReplaced] + summary[apitype.OpDelete]
+}
+
 // Destroy tears down the instance.
 func (s *StackManager) Destroy(ctx context.Context) (auto.DestroyResult, error) {
 	// For destroy, we pass an empty spec because the program function isn't strictly needed
@@ -120,16 +208,19 @@ func (s *StackManager) Destroy(ctx context.Context) (auto.DestroyResult, error)
 	// We need a program even for SelectStackInlineSource usually, but let's try SelectStack
 	// which assumes the project exists in the workspace.
 	// However, with Automation API Inline, we usually need to re-supply the program.
-	// Let's create a dummy spec for destroy purposes.
-	dummySpec := providers.InstanceSpec{Name: s.stackName}
-	program := s.provider.GetPulumiProgram(dummySpec)
+	// The program is never run, an empty spec is enough.
+	program := s.stateProgram()
 
 	stack, err := auto.UpsertStackInlineSource(ctx, s.stackName, s.project, program, auto.EnvVars(env))
 	if err != nil {
 		return auto.DestroyResult{}, fmt.Errorf("failed to select stack: %w", err)
 	}
 
-	fmt.Printf("Destroying instance '%s'...\n", s.stackName)
+	kind := map[string]string{volumeProject: "volume", networkProject: "network"}[s.project]
+	if kind == "" {
+		kind = "instance"
+	}
+	fmt.Printf("Destroying %s '%s'...\n", kind, s.stackName)
 	res, err := stack.Destroy(ctx, optdestroy.ProgressStreams(os.Stdout))
 	if err != nil {
 		return auto.DestroyResult{}, fmt.Errorf("failed to destroy stack: %w", err)
@@ -142,8 +233,7 @@ func (s *StackManager) Destroy(ctx context.Context) (auto.DestroyResult, error)
 func (s *StackManager) GetOutputs(ctx context.Context) (auto.OutputMap, error) {
 	// Reconstruct stack
 	env := s.getEnv()
-	dummySpec := providers.InstanceSpec{Name: s.stackName}
-	program := s.provider.GetPulumiProgram(dummySpec)
+	program := s.stateProgram()
 
 	stack, err := auto.UpsertStackInlineSource(ctx, s.stackName, s.project, program, auto.EnvVars(env))
 	if err != nil {
@@ -158,8 +248,17 @@ func (s *StackManager) GetOutputs(ctx context.Context) (auto.OutputMap, error) {
 	return outs, nil
 }
 
-// ListStacks returns all stack names found in the backend (file backend only).
+// ListStacks returns all instance stack names found in the backend (file backend only).
 func ListStacks(cfg *config.Profile) ([]string, error) {
+	return listStacks(cfg, "")
+}
+
+// ListVolumeStacks returns all persistent volume stack names found in the backend (file backend only).
+func ListVolumeStacks(cfg *config.Profile) ([]string, error) {
+	return listStacks(cfg, VolumesDir)
+}
+
+func listStacks(cfg *config.Profile, stateDir string) ([]string, error) {
 	backend := cfg.PulumiBackend
 	if strings.HasPrefix(backend, "file://") {
 		path := strings.TrimPrefix(backend, "file://")
@@ -167,6 +266,7 @@ func ListStacks(cfg *config.Profile) ([]string, error) {
 			dirname, _ := os.UserHomeDir()
 			path = filepath.Join(dirname, path[2:])
 		}
+		path = filepath.Join(path, stateDir)
 
 		entries, err := os.ReadDir(path)
 		if err != nil {
@@ -177,7 +277,8 @@ func ListStacks(cfg *config.Profile) ([]string, error) {
 		}
 		var stacks []string
 		for _, e := range entries {
-			if e.IsDir() {
+			// Hidden directories hold other kinds of stacks (e.g. VolumesDir)
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
 				stacks = append(stacks, e.Name())
 			}
 		}
@@ -1,8 +1,13 @@
 package orchestration
 
 import (
+	"os"
+	"path/filepath"
 	"privatebox/internal/config"
+	"reflect"
 	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 )
 
 func TestStackManager_getEnv(t *testing.T) {
@@ -10,6 +15,7 @@ func TestStackManager_getEnv(t *testing.T) {
 		name         string
 		backend      string
 		instanceName string
+		stateDir     string
 		wantBackend  string
 	}{
 		{
@@ -24,6 +30,13 @@ func TestStackManager_getEnv(t *testing.T) {
 			instanceName: "dev2",
 			wantBackend:  "file:///tmp/state/dev2",
 		},
+		{
+			name:         "File backend volume stack",
+			backend:      "file://~/.privatebox/state",
+			instanceName: "home",
+			stateDir:     VolumesDir,
+			wantBackend:  "file://~/.privatebox/state/.volumes/home",
+		},
 		{
 			name:         "S3 backend",
 			backend:      "s3://my-bucket",
@@ -47,6 +60,7 @@ func TestStackManager_getEnv(t *testing.T) {
 			s := &StackManager{
 				cfg:       cfg,
 				stackName: tt.instanceName,
+				stateDir:  tt.stateDir,
 			}
 
 			got := s.getEnv()
@@ -64,3 +78,50 @@ func TestStackManager_getEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestListStacks(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"dev1", "dev2", filepath.Join(VolumesDir, "home")} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Profile{PulumiBackend: "file://" + dir}
+
+	instances, err := ListStacks(cfg)
+	if err != nil {
+		t.Fatalf("ListStacks() error = %v", err)
+	}
+	if want := []string{"dev1", "dev2"}; !reflect.DeepEqual(instances, want) {
+		t.Errorf("ListStacks() = %v, want %v", instances, want)
+	}
+
+	volumes, err := ListVolumeStacks(cfg)
+	if err != nil {
+		t.Fatalf("ListVolumeStacks() error = %v", err)
+	}
+	if want := []string{"home"}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("ListVolumeStacks() = %v, want %v", volumes, want)
+	}
+}
+
+func TestDestructiveChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary map[apitype.OpType]int
+		want    int
+	}{
+		{name: "no changes", summary: map[apitype.OpType]int{apitype.OpSame: 9}, want: 0},
+		{name: "in-place update", summary: map[apitype.OpType]int{apitype.OpSame: 8, apitype.OpUpdate: 1}, want: 0},
+		{name: "new resource", summary: map[apitype.OpType]int{apitype.OpCreate: 1}, want: 0},
+		{name: "replacement", summary: map[apitype.OpType]int{apitype.OpUpdate: 1, apitype.OpReplace: 1}, want: 1},
+		{name: "delete", summary: map[apitype.OpType]int{apitype.OpDelete: 2}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DestructiveChanges(tt.summary); got != tt.want {
+				t.Errorf("DestructiveChanges() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package pricing provides offline price estimates for instances and storage.
+//
+// A price table is bundled with the binary and can be refreshed from the cloud
+// provider's price list; refreshed regions are stored in an override file that
+// takes precedence over the bundled data.
+package pricing
+
+import (
+	_ "embed" // Bundled price table
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HoursPerMonth is the number of hours AWS uses for monthly pricing.
+const HoursPerMonth = 730
+
+//go:embed prices.json
+var bundledTable []byte
+
+// Table holds prices per region. All prices are in USD.
+type Table struct {
+	Updated    string                  `json:"updated"`
+	BaseRegion string                  `json:"base_region"`
+	Regions    map[string]RegionPrices `json:"regions"`
+	// RegionMultipliers approximate a region's prices relative to BaseRegion
+	// for regions that have not been refreshed.
+	RegionMultipliers map[string]float64 `json:"region_multipliers,omitempty"`
+}
+
+// RegionPrices holds the prices of a single region.
+type RegionPrices struct {
+	Instances  map[string]float64 `json:"instances"`             // Linux on-demand, per hour
+	EBS        map[string]float64 `json:"ebs"`                   // Per GB-month, by volume type
+	KMSKey     float64            `json:"kms_key"`               // Per customer managed key-month
+	PublicIPv4 float64            `json:"public_ipv4,omitempty"` // Per public IPv4 address-hour (in use or elastic)
+	Updated    string             `json:"updated,omitempty"`     // Set when refreshed from the price list
+}
+
+// Load returns the bundled price table merged with the refreshed regions stored
+// at overridePath (if the file exists).
+func Load(overridePath string) (*Table, error) {
+	var table Table
+	if err := json.Unmarshal(bundledTable, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse bundled price table: %w", err)
+	}
+
+	//nolint:gosec // Path is derived from the config directory
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &table, nil
+		}
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var override Table
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", overridePath, err)
+	}
+	for region, prices := range override.Regions {
+		table.Regions[region] = prices
+	}
+	return &table, nil
+}
+
+// SaveRegion stores refreshed prices for a region in the override file,
+// keeping previously refreshed regions.
+func SaveRegion(overridePath, region string, prices RegionPrices) error {
+	override := Table{Regions: map[string]RegionPrices{}}
+
+	//nolint:gosec // Path is derived from the config directory
+	if data, err := os.ReadFile(overridePath); err == nil {
+		if err := json.Unmarshal(data, &override); err != nil {
+			return fmt.Errorf("failed to parse price table %s: %w", overridePath, err)
+		}
+		if override.Regions == nil {
+			override.Regions = map[string]RegionPrices{}
+		}
+	}
+	override.Regions[region] = prices
+
+	data, err := json.MarshalIndent(override, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal price table: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(overridePath), 0750); err != nil {
+		return fmt.Errorf("failed to create price table directory: %w", err)
+	}
+	return os.WriteFile(overridePath, data, 0600)
+}
+
+// InstanceHourly returns the on-demand hourly price of an instance type. Regions
+// that have not been refreshed are approximated from the base region.
+func (t *Table) InstanceHourly(region, instanceType string) (float64, bool) {
+	if prices, found := t.Regions[region]; found {
+		if price, found := prices.Instances[instanceType]; found {
+			return price, true
+		}
+	}
+	base, found := t.Regions[t.BaseRegion].Instances[instanceType]
+	if !found {
+		return 0, false
+	}
+	return base * t.multiplier(region), true
+}
+
+// StorageMonthly returns the monthly price of a volume.
+func (t *Table) StorageMonthly(region, volumeType string, sizeGiB int) (float64, bool) {
+	if prices, found := t.Regions[region]; found {
+		if price, found := prices.EBS[volumeType]; found {
+			return price * float64(sizeGiB), true
+		}
+	}
+	base, found := t.Regions[t.BaseRegion].EBS[volumeType]
+	if !found {
+		return 0, false
+	}
+	return base * t.multiplier(region) * float64(sizeGiB), true
+}
+
+// KMSKeyMonthly returns the monthly price of a customer managed KMS key.
+func (t *Table) KMSKeyMonthly(region string) float64 {
+	if prices, found := t.Regions[region]; found && prices.KMSKey > 0 {
+		return prices.KMSKey
+	}
+	return t.Regions[t.BaseRegion].KMSKey
+}
+
+// PublicIPv4Hourly returns the hourly price of a public IPv4 address.
+func (t *Table) PublicIPv4Hourly(region string) float64 {
+	if prices, found := t.Regions[region]; found && prices.PublicIPv4 > 0 {
+		return prices.PublicIPv4
+	}
+	return t.Regions[t.BaseRegion].PublicIPv4
+}
+
+// UpdatedFor returns when the prices used for a region were last updated.
+func (t *Table) UpdatedFor(region string) string {
+	if prices, found := t.Regions[region]; found && prices.Updated != "" {
+		return prices.Updated
+	}
+	return t.Updated + " (bundled)"
+}
+
+func (t *Table) multiplier(region string) float64 {
+	if m, ok := t.RegionMultipliers[region]; ok {
+		return m
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+package pricing
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestTable_InstanceHourly(t *testing.T) {
+	table, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		region       string
+		instanceType string
+		want         float64
+		wantOK       bool
+	}{
+		{name: "Base region", region: "us-east-1", instanceType: "t3.micro", want: 0.0104, wantOK: true},
+		{name: "Derived region", region: "eu-central-1", instanceType: "t3.micro", want: 0.0104 * 1.19, wantOK: true},
+		{name: "Unknown region uses base price", region: "xx-nowhere-1", instanceType: "t3.micro", want: 0.0104, wantOK: true},
+		{name: "Unknown type", region: "us-east-1", instanceType: "z9.huge", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.InstanceHourly(tt.region, tt.instanceType)
+			if ok != tt.wantOK {
+				t.Fatalf("InstanceHourly() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("InstanceHourly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveRegion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+
+	if err := SaveRegion(path, "eu-central-1", RegionPrices{
+		Instances: map[string]float64{"t3.micro": 0.012},
+		EBS:       map[string]float64{"gp3": 0.0952},
+		KMSKey:    1,
+	}); err != nil {
+		t.Fatalf("SaveRegion() error = %v", err)
+	}
+	if err := SaveRegion(path, "us-west-2", RegionPrices{
+		Instances: map[string]float64{"t3.micro": 0.0105},
+	}); err != nil {
+		t.Fatalf("SaveRegion() error = %v", err)
+	}
+
+	table, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got, _ := table.InstanceHourly("eu-central-1", "t3.micro"); got != 0.012 {
+		t.Errorf("refreshed eu-central-1 price = %v, want 0.012", got)
+	}
+	if got, _ := table.InstanceHourly("us-west-2", "t3.micro"); got != 0.0105 {
+		t.Errorf("refreshed us-west-2 price = %v, want 0.0105", got)
+	}
+	if got, _ := table.StorageMonthly("eu-central-1", "gp3", 10); math.Abs(got-0.952) > 1e-9 {
+		t.Errorf("StorageMonthly() = %v, want 0.952", got)
+	}
+	// Bundled regions are kept
+	if got, _ := table.InstanceHourly("us-east-1", "t3.micro"); got != 0.0104 {
+		t.Errorf("bundled us-east-1 price = %v, want 0.0104", got)
+	}
+}
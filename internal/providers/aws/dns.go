@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsroute53 "github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// createDNSRecord creates the instance's A record, pointing at its public IP (or
+// its private IP if it has none). Unless it is an elastic IP, the public IP changes
+// on every start; the CLI then updates the record (UpdateDNSRecord), so later
+// updates must not revert it.
+func createDNSRecord(ctx *pulumi.Context, spec providers.InstanceSpec, publicIP, privateIP pulumi.StringOutput) error {
+	if spec.DNS == nil {
+		return nil
+	}
+	address := pulumi.All(publicIP, privateIP).ApplyT(func(ips []interface{}) []string {
+		if public := ips[0].(string); public != "" {
+			return []string{public}
+		}
+		return []string{ips[1].(string)}
+	}).(pulumi.StringArrayOutput)
+
+	_, err := route53.NewRecord(ctx, spec.Name+"-dns", &route53.RecordArgs{
+		ZoneId:  pulumi.String(spec.DNS.ZoneID),
+		Name:    pulumi.String(spec.DNS.Name),
+		Type:    pulumi.String("A"),
+		Ttl:     pulumi.Int(spec.DNS.TTL),
+		Records: address,
+	}, pulumi.IgnoreChanges([]string{"records"}))
+	return err
+}
+
+// route53Client creates a Route 53 API client. Route 53 is a global service.
+func (p *Provider) route53Client(ctx context.Context) (*awsroute53.Client, error) {
+	cfg, err := p.awsConfig(ctx, p.cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return awsroute53.NewFromConfig(cfg), nil
+}
+
+// UpdateDNSRecord points the instance's A record at ip.
+func (p *Provider) UpdateDNSRecord(ctx context.Context, record providers.DNSRecord, ip string) error {
+	client, err := p.route53Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ChangeResourceRecordSets(ctx, &awsroute53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(record.ZoneID),
+		ChangeBatch: &route53types.ChangeBatch{
+			Comment: aws.String("privatebox: instance started"),
+			Changes: []route53types.Change{{
+				Action: route53types.ChangeActionUpsert,
+				ResourceRecordSet: &route53types.ResourceRecordSet{
+					Name:            aws.String(record.Name),
+					Type:            route53types.RRTypeA,
+					TTL:             aws.Int64(int64(record.TTL)),
+					ResourceRecords: []route53types.ResourceRecord{{Value: aws.String(ip)}},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update DNS record %s: %w", record.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"privatebox/internal/images"
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// legacyImage is the image of instances created before images were resolved at
+// create time (and stored in their spec).
+const legacyImage = "ubuntu-22.04"
+
+// ResolveImage returns the image with the query's ID, or the latest release of
+// the query's alias.
+func (p *Provider) ResolveImage(ctx context.Context, query providers.ImageQuery) (*providers.Image, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &awsec2.DescribeImagesInput{}
+	desc := query.ID
+	if query.ID != "" {
+		input.ImageIds = []string{query.ID}
+	} else {
+		alias, arch := query.Alias, query.Architecture
+		if alias == "" {
+			alias = images.DefaultImage
+		}
+		if arch == "" {
+			arch = images.ArchX86_64
+		}
+		name, owner, err := images.Lookup(alias, arch)
+		if err != nil {
+			return nil, err
+		}
+		input.Owners = []string{owner}
+		input.Filters = []ec2types.Filter{
+			{Name: aws.String("name"), Values: []string{name}},
+			{Name: aws.String("architecture"), Values: []string{arch}},
+			{Name: aws.String("virtualization-type"), Values: []string{"hvm"}},
+			{Name: aws.String("state"), Values: []string{"available"}},
+		}
+		desc = fmt.Sprintf("%s (%s)", alias, arch)
+	}
+
+	resp, err := client.DescribeImages(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe images: %w", err)
+	}
+	if len(resp.Images) == 0 {
+		return nil, fmt.Errorf("image %s not found in %s", desc, p.cfg.Region)
+	}
+
+	// Most recent first; creation dates are ISO 8601
+	sort.Slice(resp.Images, func(i, j int) bool {
+		return aws.ToString(resp.Images[i].CreationDate) > aws.ToString(resp.Images[j].CreationDate)
+	})
+	return imageInfo(resp.Images[0]), nil
+}
+
+func imageInfo(img ec2types.Image) *providers.Image {
+	info := &providers.Image{
+		ID:           aws.ToString(img.ImageId),
+		Name:         aws.ToString(img.Name),
+		OwnerID:      aws.ToString(img.OwnerId),
+		Platform:     aws.ToString(img.PlatformDetails),
+		Architecture: string(img.Architecture),
+		CreationDate: aws.ToString(img.CreationDate),
+	}
+	for _, m := range img.BlockDeviceMappings {
+		if aws.ToString(m.DeviceName) == aws.ToString(img.RootDeviceName) && m.Ebs != nil {
+			info.RootSizeGiB = int(aws.ToInt32(m.Ebs.VolumeSize))
+		}
+	}
+	return info
+}
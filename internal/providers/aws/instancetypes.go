@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// DescribeInstanceType returns an instance type offered in the profile's region.
+func (p *Provider) DescribeInstanceType(ctx context.Context, instanceType string) (*providers.InstanceType, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DescribeInstanceTypes(ctx, &awsec2.DescribeInstanceTypesInput{
+		InstanceTypes: []ec2types.InstanceType{ec2types.InstanceType(instanceType)},
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidInstanceType" {
+		return nil, fmt.Errorf("unknown instance type %s (see 'privatebox types')", instanceType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance type %s: %w", instanceType, err)
+	}
+	if len(resp.InstanceTypes) == 0 {
+		return nil, fmt.Errorf("unknown instance type %s (see 'privatebox types')", instanceType)
+	}
+
+	zones, err := instanceTypeZones(ctx, client, instanceType)
+	if err != nil {
+		return nil, err
+	}
+	info := instanceTypeInfo(resp.InstanceTypes[0], zones[instanceType])
+	if len(info.Zones) == 0 {
+		return nil, fmt.Errorf("instance type %s is not offered in %s", instanceType, p.cfg.Region)
+	}
+	return info, nil
+}
+
+// ListInstanceTypes returns the current-generation instance types offered in the
+// profile's region, sorted by name.
+func (p *Provider) ListInstanceTypes(ctx context.Context, arch string) ([]providers.InstanceType, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := instanceTypeZones(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []ec2types.Filter{
+		{Name: aws.String("current-generation"), Values: []string{"true"}},
+	}
+	if arch != "" {
+		filters = append(filters, ec2types.Filter{Name: aws.String("processor-info.supported-architecture"), Values: []string{arch}})
+	}
+
+	var types []providers.InstanceType
+	paginator := awsec2.NewDescribeInstanceTypesPaginator(client, &awsec2.DescribeInstanceTypesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instance types: %w", err)
+		}
+		for _, t := range page.InstanceTypes {
+			if z := zones[string(t.InstanceType)]; len(z) > 0 {
+				types = append(types, *instanceTypeInfo(t, z))
+			}
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types, nil
+}
+
+// instanceTypeZones returns the availability zones offering each of the instance
+// types (all types if none are given).
+func instanceTypeZones(ctx context.Context, client *awsec2.Client, instanceTypes ...string) (map[string][]string, error) {
+	input := &awsec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: ec2types.LocationTypeAvailabilityZone,
+	}
+	if len(instanceTypes) > 0 {
+		input.Filters = []ec2types.Filter{{Name: aws.String("instance-type"), Values: instanceTypes}}
+	}
+
+	zones := map[string][]string{}
+	paginator := awsec2.NewDescribeInstanceTypeOfferingsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instance type offerings: %w", err)
+		}
+		for _, o := range page.InstanceTypeOfferings {
+			t := string(o.InstanceType)
+			zones[t] = append(zones[t], aws.ToString(o.Location))
+		}
+	}
+	for _, z := range zones {
+		sort.Strings(z)
+	}
+	return zones, nil
+}
+
+func instanceTypeInfo(t ec2types.InstanceTypeInfo, zones []string) *providers.InstanceType {
+	info := &providers.InstanceType{Name: string(t.InstanceType), Zones: zones}
+	if t.VCpuInfo != nil {
+		info.VCPUs = int(aws.ToInt32(t.VCpuInfo.DefaultVCpus))
+	}
+	if t.MemoryInfo != nil {
+		info.MemoryMiB = aws.ToInt64(t.MemoryInfo.SizeInMiB)
+	}
+	if t.ProcessorInfo != nil {
+		for _, arch := range t.ProcessorInfo.SupportedArchitectures {
+			info.Architectures = append(info.Architectures, string(arch))
+		}
+	}
+	return info
+}
@@ -0,0 +1,242 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	pulumiaws "github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DefaultVPCCIDR is the address range of dedicated VPCs.
+const DefaultVPCCIDR = "10.42.0.0/16"
+
+// maxNetworkZones limits how many zones a dedicated VPC has subnets in.
+const maxNetworkZones = 3
+
+// GetNetworkProgram returns the Pulumi program of a profile's dedicated network:
+// a VPC with an internet gateway and one public subnet per zone, plus SSM
+// endpoints if requested.
+func (p *Provider) GetNetworkProgram(spec providers.NetworkSpec) pulumi.RunFunc {
+	return func(ctx *pulumi.Context) error {
+		cidr := spec.CIDR
+		if cidr == "" {
+			cidr = DefaultVPCCIDR
+		}
+
+		zones, err := pulumiaws.GetAvailabilityZones(ctx, &pulumiaws.GetAvailabilityZonesArgs{
+			State: pulumi.StringRef("available"),
+		})
+		if err != nil {
+			return err
+		}
+		names := zones.Names
+		if len(names) > maxNetworkZones {
+			names = names[:maxNetworkZones]
+		}
+
+		tagName := "privatebox-" + spec.Name
+		vpc, err := ec2.NewVpc(ctx, spec.Name+"-vpc", &ec2.VpcArgs{
+			CidrBlock:          pulumi.String(cidr),
+			EnableDnsHostnames: pulumi.Bool(true),
+			EnableDnsSupport:   pulumi.Bool(true),
+			Tags:               pulumi.StringMap{"Name": pulumi.String(tagName)},
+		})
+		if err != nil {
+			return err
+		}
+
+		igw, err := ec2.NewInternetGateway(ctx, spec.Name+"-igw", &ec2.InternetGatewayArgs{
+			VpcId: vpc.ID(),
+			Tags:  pulumi.StringMap{"Name": pulumi.String(tagName)},
+		})
+		if err != nil {
+			return err
+		}
+
+		routes, err := ec2.NewRouteTable(ctx, spec.Name+"-public", &ec2.RouteTableArgs{
+			VpcId: vpc.ID(),
+			Routes: ec2.RouteTableRouteArray{
+				&ec2.RouteTableRouteArgs{
+					CidrBlock: pulumi.String("0.0.0.0/0"),
+					GatewayId: igw.ID(),
+				},
+			},
+			Tags: pulumi.StringMap{"Name": pulumi.String(tagName + "-public")},
+		})
+		if err != nil {
+			return err
+		}
+
+		subnets := pulumi.StringMap{}
+		var first *ec2.Subnet
+		for i, zone := range names {
+			block, err := subnetCIDR(cidr, i)
+			if err != nil {
+				return err
+			}
+			subnet, err := ec2.NewSubnet(ctx, fmt.Sprintf("%s-public-%s", spec.Name, zone), &ec2.SubnetArgs{
+				VpcId:               vpc.ID(),
+				CidrBlock:           pulumi.String(block),
+				AvailabilityZone:    pulumi.String(zone),
+				MapPublicIpOnLaunch: pulumi.Bool(true),
+				Tags:                pulumi.StringMap{"Name": pulumi.String(fmt.Sprintf("%s-public-%s", tagName, zone))},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = ec2.NewRouteTableAssociation(ctx, fmt.Sprintf("%s-public-%s", spec.Name, zone), &ec2.RouteTableAssociationArgs{
+				SubnetId:     subnet.ID(),
+				RouteTableId: routes.ID(),
+			})
+			if err != nil {
+				return err
+			}
+			subnets[zone] = subnet.ID().ToStringOutput()
+			if first == nil {
+				first = subnet
+			}
+		}
+
+		if spec.SSMEndpoints {
+			tags := pulumi.StringMap{"Name": pulumi.String(tagName + "-ssm")}
+			if err := createSSMEndpoints(ctx, spec.Name, p.cfg.Region, cidr, vpc, first, tags); err != nil {
+				return err
+			}
+		}
+
+		ctx.Export("vpcID", vpc.ID())
+		ctx.Export("subnets", subnets)
+		ctx.Export("ssmEndpoints", pulumi.Bool(spec.SSMEndpoints))
+		return nil
+	}
+}
+
+// subnetCIDR returns the i-th /20 (or 4 bits smaller) subnet of a VPC's range.
+func subnetCIDR(vpcCIDR string, i int) (string, error) {
+	_, ipNet, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid vpc_cidr %q: %w", vpcCIDR, err)
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return "", fmt.Errorf("vpc_cidr %s is not an IPv4 range", vpcCIDR)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	// AWS subnets are at most /28
+	const subnetBits, maxPrefix = 4, 28
+	if ones+subnetBits > maxPrefix || i >= 1<<subnetBits {
+		return "", fmt.Errorf("vpc_cidr %s is too small", vpcCIDR)
+	}
+	offset := uint32(i) << uint(bits-ones-subnetBits)
+	base := uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+	base += offset
+	subnet := net.IPv4(byte(base>>24), byte(base>>16), byte(base>>8), byte(base))
+	return fmt.Sprintf("%s/%d", subnet, ones+subnetBits), nil
+}
+
+// FindSubnet returns the subnet matching the query with the most free addresses.
+func (p *Provider) FindSubnet(ctx context.Context, query providers.SubnetQuery) (*providers.Network, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []ec2types.Filter
+	switch {
+	case query.SubnetID != "":
+		filters = append(filters, ec2types.Filter{Name: aws.String("subnet-id"), Values: []string{query.SubnetID}})
+	case query.VPCID != "":
+		filters = append(filters, ec2types.Filter{Name: aws.String("vpc-id"), Values: []string{query.VPCID}})
+	case len(query.Tags) == 0:
+		filters = append(filters, ec2types.Filter{Name: aws.String("default-for-az"), Values: []string{"true"}})
+	}
+	if query.SubnetID == "" {
+		for k, v := range query.Tags {
+			filters = append(filters, ec2types.Filter{Name: aws.String("tag:" + k), Values: []string{v}})
+		}
+	}
+
+	resp, err := client.DescribeSubnets(ctx, &awsec2.DescribeSubnetsInput{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := resp.Subnets
+	if len(subnets) == 0 {
+		if len(filters) == 1 && aws.ToString(filters[0].Name) == "default-for-az" {
+			return nil, fmt.Errorf("region %s has no default VPC: set aws.vpc_id, aws.subnet_id or aws.create_vpc in the profile", p.cfg.Region)
+		}
+		return nil, fmt.Errorf("no subnet matches the profile's aws.subnet_id, aws.vpc_id and aws.subnet_tags")
+	}
+
+	if len(query.AvailabilityZones) > 0 {
+		var inZone []ec2types.Subnet
+		for _, s := range subnets {
+			if slices.Contains(query.AvailabilityZones, aws.ToString(s.AvailabilityZone)) {
+				inZone = append(inZone, s)
+			}
+		}
+		if len(inZone) == 0 {
+			return nil, fmt.Errorf("no matching subnet in %s", strings.Join(query.AvailabilityZones, ", "))
+		}
+		subnets = inZone
+	}
+
+	sort.SliceStable(subnets, func(i, j int) bool {
+		return aws.ToInt32(subnets[i].AvailableIpAddressCount) > aws.ToInt32(subnets[j].AvailableIpAddressCount)
+	})
+	s := subnets[0]
+	return &providers.Network{
+		VPCID:            aws.ToString(s.VpcId),
+		SubnetID:         aws.ToString(s.SubnetId),
+		AvailabilityZone: aws.ToString(s.AvailabilityZone),
+	}, nil
+}
+
+// instanceNetwork returns the subnet settings of the instance. Instances created by
+// older versions have no network in their spec and stay in the default VPC.
+func instanceNetwork(spec providers.InstanceSpec) (subnetID pulumi.StringPtrInput, publicIP pulumi.BoolPtrInput) {
+	if spec.Network == nil {
+		return nil, nil
+	}
+	return pulumi.String(spec.Network.SubnetID), pulumi.Bool(spec.Network.AssociatePublicIP)
+}
+
+// securityGroupVPC returns the VPC of the instance's security group (nil: default VPC).
+func securityGroupVPC(spec providers.InstanceSpec) pulumi.StringPtrInput {
+	if spec.Network == nil {
+		return nil
+	}
+	return pulumi.String(spec.Network.VPCID)
+}
+
+// createElasticIP allocates a fixed public address for the instance and returns it,
+// or the instance's own public IP if it has none.
+func createElasticIP(ctx *pulumi.Context, spec providers.InstanceSpec, srv *ec2.Instance) (pulumi.StringOutput, error) {
+	if !spec.ElasticIP {
+		return srv.PublicIp, nil
+	}
+	eip, err := ec2.NewEip(ctx, spec.Name+"-eip", &ec2.EipArgs{
+		Domain:   pulumi.String("vpc"),
+		Instance: srv.ID(),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(spec.Name + "-eip"),
+		},
+	})
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	return eip.PublicIp, nil
+}
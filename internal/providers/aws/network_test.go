@@ -0,0 +1,36 @@
+package aws
+
+import "testing"
+
+func TestSubnetCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		vpcCIDR string
+		index   int
+		want    string
+		wantErr bool
+	}{
+		{name: "default first", vpcCIDR: DefaultVPCCIDR, index: 0, want: "10.42.0.0/20"},
+		{name: "default second", vpcCIDR: DefaultVPCCIDR, index: 1, want: "10.42.16.0/20"},
+		{name: "default last", vpcCIDR: DefaultVPCCIDR, index: 15, want: "10.42.240.0/20"},
+		{name: "default out of subnets", vpcCIDR: DefaultVPCCIDR, index: 16, wantErr: true},
+		{name: "host bits are ignored", vpcCIDR: "10.42.7.9/16", index: 2, want: "10.42.32.0/20"},
+		{name: "small range", vpcCIDR: "192.168.0.0/22", index: 3, want: "192.168.0.192/26"},
+		{name: "smallest splittable range", vpcCIDR: "192.168.1.0/24", index: 15, want: "192.168.1.240/28"},
+		{name: "too small to split", vpcCIDR: "192.168.1.0/25", index: 0, wantErr: true},
+		{name: "ipv6", vpcCIDR: "fd00::/56", index: 0, wantErr: true},
+		{name: "invalid", vpcCIDR: "10.42.0.0", index: 0, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := subnetCIDR(tc.vpcCIDR, tc.index)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("subnetCIDR(%q, %d) error = %v, wantErr %v", tc.vpcCIDR, tc.index, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("subnetCIDR(%q, %d) = %q, want %q", tc.vpcCIDR, tc.index, got, tc.want)
+			}
+		})
+	}
+}
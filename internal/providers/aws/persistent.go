@@ -0,0 +1,176 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	pulumiaws "github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ebs"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DefaultHomeMountPoint is where persistent volumes are mounted unless configured otherwise.
+const DefaultHomeMountPoint = "/home"
+
+// homeVolumeDevice is the device name persistent volumes are attached as. It is
+// the last of the recommended EBS device names; data volumes use the ones before.
+const homeVolumeDevice = "/dev/sdp"
+
+// GetVolumeProgram returns the Pulumi program of a persistent volume: an encrypted
+// EBS volume with its own KMS key.
+func (p *Provider) GetVolumeProgram(spec providers.VolumeSpec) pulumi.RunFunc {
+	return func(ctx *pulumi.Context) error {
+		if spec.Volume.SizeGiB <= 0 {
+			return fmt.Errorf("persistent volume size is required")
+		}
+
+		caller, err := pulumiaws.GetCallerIdentity(ctx, nil)
+		if err != nil {
+			return err
+		}
+		principalArn := p.getPrincipalARN(caller.Arn)
+
+		// Only the creator can use the key. Instances it is attached to are launched by the
+		// creator too; the scheduler roles of instances get grants (see attachHomeVolume).
+		statements := keyAdminStatements(principalArn, caller.AccountId)
+
+		key, err := kms.NewKey(ctx, spec.Name+"-key", &kms.KeyArgs{
+			Description:          pulumi.String("Key for persistent volume " + spec.Name),
+			Policy:               pulumi.String(keyPolicyDocument(statements)),
+			DeletionWindowInDays: pulumi.Int(7),
+		})
+		if err != nil {
+			return err
+		}
+
+		v := spec.Volume
+		volumeType := v.Type
+		if volumeType == "" {
+			volumeType = defaultVolumeType
+		}
+		zone := v.AvailabilityZone
+		if zone == "" {
+			return fmt.Errorf("persistent volume availability zone is required")
+		}
+		args := &ebs.VolumeArgs{
+			AvailabilityZone: pulumi.String(zone),
+			Size:             pulumi.Int(v.SizeGiB),
+			Type:             pulumi.String(volumeType),
+			Encrypted:        pulumi.Bool(true),
+			KmsKeyId:         key.Arn,
+			Tags: pulumi.StringMap{
+				"Name": pulumi.String("privatebox-volume-" + spec.Name),
+			},
+		}
+		if v.IOPS > 0 {
+			args.Iops = pulumi.Int(v.IOPS)
+		}
+		if v.Throughput > 0 {
+			args.Throughput = pulumi.Int(v.Throughput)
+		}
+
+		vol, err := ebs.NewVolume(ctx, spec.Name, args)
+		if err != nil {
+			return err
+		}
+
+		mountPoint := v.MountPoint
+		if mountPoint == "" {
+			mountPoint = DefaultHomeMountPoint
+		}
+
+		ctx.Export("volumeID", vol.ID())
+		ctx.Export("availabilityZone", vol.AvailabilityZone)
+		ctx.Export("keyARN", key.Arn)
+		ctx.Export("mountPoint", pulumi.String(mountPoint))
+		if spec.ProfileName != "" {
+			ctx.Export("profileName", pulumi.String(spec.ProfileName))
+		}
+		return nil
+	}
+}
+
+// attachHomeVolume attaches the spec's persistent volume. Destroying the instance only
+// removes the attachment; the volume stays in its own stack. If the instance has a
+// scheduler role, it gets a grant on the volume's key, which starting the instance
+// requires; the grant is retired when the instance is destroyed.
+func attachHomeVolume(ctx *pulumi.Context, spec providers.InstanceSpec, srv *ec2.Instance, schedulerRole *iam.Role) error {
+	home := spec.HomeVolume
+	if home == nil {
+		return nil
+	}
+	_, err := ec2.NewVolumeAttachment(ctx, spec.Name+"-home", &ec2.VolumeAttachmentArgs{
+		DeviceName:                  pulumi.String(homeVolumeDevice),
+		InstanceId:                  srv.ID(),
+		VolumeId:                    pulumi.String(home.VolumeID),
+		StopInstanceBeforeDetaching: pulumi.Bool(true),
+	})
+	if err != nil || schedulerRole == nil {
+		return err
+	}
+
+	// EC2 creates its own grant for the volume when the role starts the instance,
+	// which can only include operations of this grant.
+	_, err = kms.NewGrant(ctx, spec.Name+"-home-grant", &kms.GrantArgs{
+		KeyId:            pulumi.String(home.KeyARN),
+		GranteePrincipal: schedulerRole.Arn,
+		Operations: pulumi.ToStringArray([]string{
+			"CreateGrant", "Decrypt", "DescribeKey", "GenerateDataKeyWithoutPlaintext", "ReEncryptFrom", "ReEncryptTo",
+		}),
+	})
+	return err
+}
+
+// instanceZone returns the zone the instance must be placed in, or nil to let AWS (or
+// the subnet) choose.
+func instanceZone(spec providers.InstanceSpec) pulumi.StringPtrInput {
+	if spec.HomeVolume == nil || spec.Network != nil {
+		return nil
+	}
+	return pulumi.String(spec.HomeVolume.AvailabilityZone)
+}
+
+// GetVolume returns a single volume.
+func (p *Provider) GetVolume(ctx context.Context, volumeID string) (*providers.VolumeInfo, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DescribeVolumes(ctx, &awsec2.DescribeVolumesInput{
+		VolumeIds: []string{volumeID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Volumes) == 0 {
+		return nil, fmt.Errorf("volume %s not found", volumeID)
+	}
+	info := volumeInfo(resp.Volumes[0])
+	return &info, nil
+}
+
+func volumeInfo(v ec2types.Volume) providers.VolumeInfo {
+	info := providers.VolumeInfo{
+		ID:      aws.ToString(v.VolumeId),
+		Type:    string(v.VolumeType),
+		SizeGiB: int(aws.ToInt32(v.Size)),
+		State:   string(v.State),
+	}
+	if v.CreateTime != nil {
+		info.CreatedAt = *v.CreateTime
+	}
+	if len(v.Attachments) > 0 {
+		info.Device = aws.ToString(v.Attachments[0].Device)
+		info.Attached = aws.ToString(v.Attachments[0].InstanceId)
+	}
+	return info
+}
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"privatebox/internal/pricing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+)
+
+// pricingRegion hosts the AWS Price List API endpoint.
+const pricingRegion = "us-east-1"
+
+// defaultKMSKeyMonthly is the price of a customer managed KMS key (same in all regions).
+const defaultKMSKeyMonthly = 1.0
+
+// defaultPublicIPv4Hourly is the price of a public IPv4 address (same in all regions).
+const defaultPublicIPv4Hourly = 0.005
+
+// priceListProduct is the subset of a Price List API product document we need.
+type priceListProduct struct {
+	Product struct {
+		Attributes map[string]string `json:"attributes"`
+	} `json:"product"`
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]struct {
+				Unit         string            `json:"unit"`
+				PricePerUnit map[string]string `json:"pricePerUnit"`
+			} `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+// onDemandUSD returns the first on-demand USD price of the product.
+func (p priceListProduct) onDemandUSD() (float64, bool) {
+	for _, term := range p.Terms.OnDemand {
+		for _, dim := range term.PriceDimensions {
+			if usd, ok := dim.PricePerUnit["USD"]; ok {
+				price, err := strconv.ParseFloat(usd, 64)
+				return price, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// FetchPrices downloads Linux on-demand instance and EBS prices for the profile's
+// region from the AWS Price List API.
+func (p *Provider) FetchPrices(ctx context.Context) (*pricing.RegionPrices, error) {
+	cfg, err := p.awsConfig(ctx, pricingRegion)
+	if err != nil {
+		return nil, err
+	}
+	client := awspricing.NewFromConfig(cfg)
+
+	prices := &pricing.RegionPrices{
+		Instances:  map[string]float64{},
+		EBS:        map[string]float64{},
+		KMSKey:     defaultKMSKeyMonthly,
+		PublicIPv4: defaultPublicIPv4Hourly,
+		Updated:    time.Now().UTC().Format(time.DateOnly),
+	}
+
+	instanceFilters := map[string]string{
+		"regionCode":      p.cfg.Region,
+		"productFamily":   "Compute Instance",
+		"operatingSystem": "Linux",
+		"tenancy":         "Shared",
+		"preInstalledSw":  "NA",
+		"capacitystatus":  "Used",
+		"licenseModel":    "No License required",
+	}
+	err = p.eachProduct(ctx, client, instanceFilters, func(product priceListProduct) {
+		if price, ok := product.onDemandUSD(); ok && price > 0 {
+			prices.Instances[product.Product.Attributes["instanceType"]] = price
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storageFilters := map[string]string{
+		"regionCode":    p.cfg.Region,
+		"productFamily": "Storage",
+	}
+	err = p.eachProduct(ctx, client, storageFilters, func(product priceListProduct) {
+		if price, ok := product.onDemandUSD(); ok {
+			prices.EBS[product.Product.Attributes["volumeApiName"]] = price
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prices.Instances) == 0 {
+		return nil, fmt.Errorf("no prices found for region %s", p.cfg.Region)
+	}
+	return prices, nil
+}
+
+// eachProduct calls fn for every AmazonEC2 product matching the filters.
+func (p *Provider) eachProduct(ctx context.Context, client *awspricing.Client, filters map[string]string, fn func(priceListProduct)) error {
+	var input awspricing.GetProductsInput
+	input.ServiceCode = aws.String("AmazonEC2")
+	for field, value := range filters {
+		input.Filters = append(input.Filters, pricingtypes.Filter{
+			Type:  pricingtypes.FilterTypeTermMatch,
+			Field: aws.String(field),
+			Value: aws.String(value),
+		})
+	}
+
+	paginator := awspricing.NewGetProductsPaginator(client, &input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to query price list: %w", err)
+		}
+		for _, doc := range page.PriceList {
+			var product priceListProduct
+			if err := json.Unmarshal([]byte(doc), &product); err != nil {
+				return fmt.Errorf("failed to parse price list: %w", err)
+			}
+			fn(product)
+		}
+	}
+	return nil
+}
@@ -3,17 +3,24 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"privatebox/internal/config"
+	"privatebox/internal/images"
 	"privatebox/internal/providers"
+	"privatebox/internal/userdata"
 
 	// AWS SDK v2
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
 	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 
 	// Pulumi AWS
 	pulumiaws "github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
@@ -23,6 +30,9 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
+// DefaultInstanceType is used when neither the instance nor the profile sets a type.
+const DefaultInstanceType = "t3.micro"
+
 // Provider implements the CloudProvider interface for AWS.
 type Provider struct {
 	cfg config.Profile
@@ -38,18 +48,19 @@ func (p *Provider) Name() string {
 	return "aws"
 }
 
-// GetSSHUser returns the default SSH user for the instance.
+// GetSSHUser returns the SSH user of instances whose image user is unknown
+// (created by older versions, which used Ubuntu images by default).
 func (p *Provider) GetSSHUser() string {
-	// For Amazon Linux 2 or Ubuntu, it varies.
-	// We'll default to "ubuntu" for now as we'll use Ubuntu AMIs by default,
-	// or "ec2-user" for Amazon Linux.
-	// To be safe, let's assume Ubuntu for this MVP.
-	return "ubuntu"
+	return images.DefaultSSHUser
 }
 
 // GetPulumiProgram returns the Pulumi program to infrastructure.
 func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc {
 	return func(ctx *pulumi.Context) error {
+		if err := validateVolumes(spec); err != nil {
+			return err
+		}
+
 		// 0. Get Caller Identity to secure KMS key
 		caller, err := pulumiaws.GetCallerIdentity(ctx, nil)
 		if err != nil {
@@ -59,100 +70,53 @@ func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc
 		// Normalize ARN (if assumed-role, get the role ARN to prevent locking out if session expires)
 		principalArn := p.getPrincipalARN(caller.Arn)
 
+		// 0.25 Resolve the start/stop schedule. The scheduler's role must exist before
+		// the KMS key, because starting an instance requires a grant on its volume key.
+		sched, err := p.resolveSchedule(spec)
+		if err != nil {
+			return err
+		}
+
 		// 0.5 Create KMS Key
 		// Restrictive policy: Only the creator (current user) has full access.
 		// However, we allow the account root (delegated admins) to schedule deletion.
 		// This ensures if the user leaves, an admin can clean up the resource without being able to decrypt it.
-		keyPolicy := fmt.Sprintf(`{
-			"Version": "2012-10-17",
-			"Statement": [
-				{
-					"Sid": "Allow access for Key Administrator",
-					"Effect": "Allow",
-					"Principal": {
-						"AWS": "%s"
-					},
-					"Action": "kms:*",
-					"Resource": "*"
-				},
-				{
-					"Sid": "Allow Account Root to Schedule Deletion",
-					"Effect": "Allow",
-					"Principal": {
-						"AWS": "arn:aws:iam::%s:root"
-					},
-					"Action": [
-						"kms:ScheduleKeyDeletion",
-						"kms:Delete*",
-						"kms:DescribeKey"
-					],
-					"Resource": "*"
-				}
-			]
-		}`, principalArn, caller.AccountId)
+		keyPolicy := pulumi.String(buildKeyPolicy(principalArn, caller.AccountId)).ToStringOutput()
+
+		var schedulerRole *iam.Role
+		if sched != nil {
+			schedulerRole, err = newSchedulerRole(ctx, spec.Name)
+			if err != nil {
+				return err
+			}
+			keyPolicy = schedulerRole.Arn.ApplyT(func(arn string) string {
+				return buildKeyPolicy(principalArn, caller.AccountId, arn)
+			}).(pulumi.StringOutput)
+		}
 
+		var keyOpts []pulumi.ResourceOption
+		if retainsDataVolumes(spec) {
+			// Volumes kept on destroy stay encrypted with the key, so it must outlive the instance
+			keyOpts = append(keyOpts, pulumi.RetainOnDelete(true))
+		}
 		key, err := kms.NewKey(ctx, spec.Name+"-key", &kms.KeyArgs{
 			Description:          pulumi.String("Key for " + spec.Name),
-			Policy:               pulumi.String(keyPolicy),
+			Policy:               keyPolicy,
 			DeletionWindowInDays: pulumi.Int(7),
-		})
+		}, keyOpts...)
 		if err != nil {
 			return err
 		}
 
 		// 1. Create Security Group
-		// Prepare Ingress Rules
-		var ingressRules ec2.SecurityGroupIngressArray
-		if len(p.cfg.AWS.IngressRules) > 0 {
-			for _, rule := range p.cfg.AWS.IngressRules {
-				cidrs := pulumi.StringArray{}
-				for _, c := range rule.CidrBlocks {
-					cidrs = append(cidrs, pulumi.String(c))
-				}
-				ingressRules = append(ingressRules, &ec2.SecurityGroupIngressArgs{
-					Protocol:   pulumi.String(rule.Protocol),
-					FromPort:   pulumi.Int(rule.FromPort),
-					ToPort:     pulumi.Int(rule.ToPort),
-					CidrBlocks: cidrs,
-				})
-			}
-		} else {
-			// Default: Allow SSH from anywhere
-			ingressRules = append(ingressRules, &ec2.SecurityGroupIngressArgs{
-				Protocol:   pulumi.String("tcp"),
-				FromPort:   pulumi.Int(22),
-				ToPort:     pulumi.Int(22),
-				CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-			})
-		}
-
-		// Prepare Egress Rules
-		var egressRules ec2.SecurityGroupEgressArray
-		if len(p.cfg.AWS.EgressRules) > 0 {
-			for _, rule := range p.cfg.AWS.EgressRules {
-				cidrs := pulumi.StringArray{}
-				for _, c := range rule.CidrBlocks {
-					cidrs = append(cidrs, pulumi.String(c))
-				}
-				egressRules = append(egressRules, &ec2.SecurityGroupEgressArgs{
-					Protocol:   pulumi.String(rule.Protocol),
-					FromPort:   pulumi.Int(rule.FromPort),
-					ToPort:     pulumi.Int(rule.ToPort),
-					CidrBlocks: cidrs,
-				})
-			}
-		} else {
-			// Default: Allow all outbound
-			egressRules = append(egressRules, &ec2.SecurityGroupEgressArgs{
-				Protocol:   pulumi.String("-1"),
-				FromPort:   pulumi.Int(0),
-				ToPort:     pulumi.Int(0),
-				CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-			})
+		ingressRules, egressRules, err := p.securityGroupRules(spec)
+		if err != nil {
+			return err
 		}
 
 		sg, err := ec2.NewSecurityGroup(ctx, spec.Name+"-sg", &ec2.SecurityGroupArgs{
 			Description: pulumi.String("Security Group for " + spec.Name),
+			VpcId:       securityGroupVPC(spec),
 			Ingress:     ingressRules,
 			Egress:      egressRules,
 			Tags: pulumi.StringMap{
@@ -226,24 +190,31 @@ func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc
 			keyName = key.KeyName
 		}
 
-		// 3. Find AMI (Ubuntu 22.04 LTS)
-		amiID := p.cfg.AWS.AMI
+		// 3. Find AMI. It is resolved at create time (see ResolveImage); instances
+		// created by older versions use the profile's AMI or the latest Ubuntu 22.04.
+		amiID := spec.AMI
+		if amiID == "" {
+			amiID = p.cfg.AWS.AMI
+		}
 		if amiID == "" {
-			// Lookup latest Ubuntu 22.04
+			name, owner, err := images.Lookup(legacyImage, images.ArchX86_64)
+			if err != nil {
+				return err
+			}
 			mostRecent := true
 			ubuntu, err := ec2.LookupAmi(ctx, &ec2.LookupAmiArgs{
 				MostRecent: &mostRecent,
 				Filters: []ec2.GetAmiFilter{
 					{
 						Name:   "name",
-						Values: []string{"ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"},
+						Values: []string{name},
 					},
 					{
 						Name:   "virtualization-type",
 						Values: []string{"hvm"},
 					},
 				},
-				Owners: []string{"099720109477"}, // Canonical
+				Owners: []string{owner},
 			})
 			if err != nil {
 				return err
@@ -252,9 +223,12 @@ func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc
 		}
 
 		// 4. Create Instance
-		instanceType := p.cfg.AWS.InstanceType
+		instanceType := spec.Type
 		if instanceType == "" {
-			instanceType = "t3.micro"
+			instanceType = p.cfg.AWS.InstanceType
+		}
+		if instanceType == "" {
+			instanceType = DefaultInstanceType
 		}
 
 		// Prepare tags
@@ -267,33 +241,86 @@ func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc
 			pulumiTags[k] = pulumi.String(v)
 		}
 
+		userData, idlePolicy, err := buildUserData(spec)
+		if err != nil {
+			return err
+		}
+
+		subnetID, publicIP := instanceNetwork(spec)
 		srv, err := ec2.NewInstance(ctx, spec.Name, &ec2.InstanceArgs{
 			InstanceType:        pulumi.String(instanceType),
 			VpcSecurityGroupIds: pulumi.StringArray{sg.ID()},
 			Ami:                 pulumi.String(amiID),
 			KeyName:             keyName,
-			UserData:            pulumi.String(spec.UserData),
+			UserData:            pulumi.String(userData),
 			Tags:                pulumiTags,
 			IamInstanceProfile:  instanceProfile.Name,
-			RootBlockDevice: &ec2.InstanceRootBlockDeviceArgs{
-				VolumeType:          pulumi.String("gp3"),
-				Encrypted:           pulumi.Bool(true),
-				KmsKeyId:            key.Arn,
-				DeleteOnTermination: pulumi.Bool(true),
-			},
-		})
+			// Hibernation can only be enabled at launch. It requires an encrypted root volume,
+			// which we always use.
+			Hibernation:              pulumi.Bool(spec.Hibernation != nil && *spec.Hibernation),
+			InstanceMarketOptions:    spotMarketOptions(spec.Spot),
+			RootBlockDevice:          rootBlockDevice(spec, key),
+			AvailabilityZone:         instanceZone(spec),
+			SubnetId:                 subnetID,
+			AssociatePublicIpAddress: publicIP,
+		},
+			// The AMI lookup returns the most recent image; don't replace the instance
+			// when a newer one is published and the stack is updated (e.g. volume resize).
+			pulumi.IgnoreChanges([]string{"ami"}),
+		)
 		if err != nil {
 			return err
 		}
 
+		// 4.25 Data volumes
+		if err := createDataVolumes(ctx, spec, key, srv); err != nil {
+			return err
+		}
+		if err := attachHomeVolume(ctx, spec, srv, schedulerRole); err != nil {
+			return err
+		}
+
+		// 4.3 Public address and DNS name
+		address, err := createElasticIP(ctx, spec, srv)
+		if err != nil {
+			return err
+		}
+		if err := createDNSRecord(ctx, spec, address, srv.PrivateIp); err != nil {
+			return err
+		}
+
+		// 4.5 Start/stop schedule
+		scheduleDesc := ""
+		if sched != nil {
+			if err := createSchedules(ctx, spec.Name, sched, schedulerRole, srv); err != nil {
+				return err
+			}
+			scheduleDesc = sched.String()
+		}
+
 		// 5. Export Outputs
 		ctx.Export("instanceID", srv.ID())
-		ctx.Export("publicIP", srv.PublicIp)
+		ctx.Export("publicIP", address)
 		ctx.Export("privateIP", srv.PrivateIp)
 		ctx.Export("publicDNS", srv.PublicDns)
 		if spec.ProfileName != "" {
 			ctx.Export("profileName", pulumi.String(spec.ProfileName))
 		}
+		ctx.Export("idleShutdown", pulumi.String(idlePolicy))
+		ctx.Export("schedule", pulumi.String(scheduleDesc))
+		ctx.Export("connectMode", pulumi.String(connectMode(spec)))
+		ctx.Export("sshUser", pulumi.String(spec.SSHUser))
+		hostname := ""
+		if spec.DNS != nil {
+			hostname = spec.DNS.Name
+		}
+		ctx.Export("hostname", pulumi.String(hostname))
+		if spec.ElasticIP {
+			ctx.Export("elasticIP", address)
+		} else {
+			ctx.Export("elasticIP", pulumi.String(""))
+		}
+		ctx.Export(providers.SpecOutput, pulumi.String(spec.JSON()))
 		if v, ok := pulumiTags["UserDataName"]; ok {
 			ctx.Export("userDataName", v)
 		} else {
@@ -303,6 +330,149 @@ func (p *Provider) GetPulumiProgram(spec providers.InstanceSpec) pulumi.RunFunc
 	}
 }
 
+// securityGroupRules returns the instance's firewall rules with config.MyIP resolved.
+// Instances created by older versions have no rules in their spec and keep the ones
+// they were created with.
+func (p *Provider) securityGroupRules(spec providers.InstanceSpec) (ec2.SecurityGroupIngressArray, ec2.SecurityGroupEgressArray, error) {
+	ingress := spec.IngressRules
+	if ingress == nil {
+		ingress = p.cfg.LegacyIngressRulesFor(spec.ConnectMode)
+	}
+	egress := p.cfg.AWS.EgressRules
+	if len(egress) == 0 {
+		// Default: Allow all outbound
+		egress = []config.SecurityGroupRule{{Protocol: "-1", FromPort: 0, ToPort: 0, CidrBlocks: []string{"0.0.0.0/0"}}}
+	}
+
+	if (config.UsesMyIP(ingress) || config.UsesMyIP(egress)) && spec.MyIP == "" {
+		return nil, nil, fmt.Errorf("firewall rules use %s, but the address was not resolved", config.MyIP)
+	}
+	ingress = config.ExpandMyIP(ingress, spec.MyIP)
+	egress = config.ExpandMyIP(egress, spec.MyIP)
+
+	// Non-nil, so that removing the last rule removes it from the security group
+	ingressRules := ec2.SecurityGroupIngressArray{}
+	for _, rule := range ingress {
+		ingressRules = append(ingressRules, &ec2.SecurityGroupIngressArgs{
+			Protocol:   pulumi.String(rule.Protocol),
+			FromPort:   pulumi.Int(rule.FromPort),
+			ToPort:     pulumi.Int(rule.ToPort),
+			CidrBlocks: pulumi.ToStringArray(rule.CidrBlocks),
+		})
+	}
+	var egressRules ec2.SecurityGroupEgressArray
+	for _, rule := range egress {
+		egressRules = append(egressRules, &ec2.SecurityGroupEgressArgs{
+			Protocol:   pulumi.String(rule.Protocol),
+			FromPort:   pulumi.Int(rule.FromPort),
+			ToPort:     pulumi.Int(rule.ToPort),
+			CidrBlocks: pulumi.ToStringArray(rule.CidrBlocks),
+		})
+	}
+	return ingressRules, egressRules, nil
+}
+
+// buildUserData combines the user's script with the scripts privatebox installs
+// itself. It also returns a description of the idle policy ("" if disabled).
+func buildUserData(spec providers.InstanceSpec) (string, string, error) {
+	parts := []userdata.Part{{Filename: "user-data", Content: spec.UserData}}
+
+	idlePolicy := ""
+	if idle := spec.IdleShutdown; idle != nil && idle.After != "" {
+		after, err := time.ParseDuration(idle.After)
+		if err != nil || after <= 0 {
+			return "", "", fmt.Errorf("invalid idle_shutdown.after %q", idle.After)
+		}
+		watchdog := userdata.IdleWatchdog(after, idle.CPUThreshold)
+		parts = append(parts, watchdog)
+
+		threshold := idle.CPUThreshold
+		if threshold <= 0 {
+			threshold = userdata.DefaultIdleCPUThreshold
+		}
+		idlePolicy = fmt.Sprintf("after %s (no sessions, CPU < %d%%)", after, threshold)
+	}
+
+	parts = append(parts, userdata.MountVolumes(dataVolumeMounts(spec)))
+
+	content, err := userdata.Compose(parts...)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build user data: %w", err)
+	}
+	return content, idlePolicy, nil
+}
+
+// buildKeyPolicy returns the KMS key policy. instanceStarters are additional principals
+// (e.g. the scheduler role) that may start the instance and therefore need to create
+// grants for its encrypted volumes.
+func buildKeyPolicy(principalArn, accountID string, instanceStarters ...string) string {
+	statements := keyAdminStatements(principalArn, accountID)
+
+	for i, arn := range instanceStarters {
+		statements = append(statements, fmt.Sprintf(`{
+			"Sid": "Allow Instance Starter %[1]d To Describe Key",
+			"Effect": "Allow",
+			"Principal": {
+				"AWS": "%[2]s"
+			},
+			"Action": "kms:DescribeKey",
+			"Resource": "*"
+		}`, i, arn), fmt.Sprintf(`{
+			"Sid": "Allow Instance Starter %[1]d To Create EBS Grants",
+			"Effect": "Allow",
+			"Principal": {
+				"AWS": "%[2]s"
+			},
+			"Action": "kms:CreateGrant",
+			"Resource": "*",
+			"Condition": {
+				"Bool": {
+					"kms:GrantIsForAWSResource": "true"
+				}
+			}
+		}`, i, arn))
+	}
+
+	return keyPolicyDocument(statements)
+}
+
+// keyAdminStatements give the creator full access to a key and let the account
+// root schedule its deletion.
+func keyAdminStatements(principalArn, accountID string) []string {
+	return []string{
+		fmt.Sprintf(`{
+			"Sid": "Allow access for Key Administrator",
+			"Effect": "Allow",
+			"Principal": {
+				"AWS": "%s"
+			},
+			"Action": "kms:*",
+			"Resource": "*"
+		}`, principalArn),
+		fmt.Sprintf(`{
+			"Sid": "Allow Account Root to Schedule Deletion",
+			"Effect": "Allow",
+			"Principal": {
+				"AWS": "arn:aws:iam::%s:root"
+			},
+			"Action": [
+				"kms:ScheduleKeyDeletion",
+				"kms:Delete*",
+				"kms:DescribeKey"
+			],
+			"Resource": "*"
+		}`, accountID),
+	}
+}
+
+// keyPolicyDocument wraps key policy statements in a policy document.
+func keyPolicyDocument(statements []string) string {
+	return fmt.Sprintf(`{
+		"Version": "2012-10-17",
+		"Statement": [%s]
+	}`, strings.Join(statements, ","))
+}
+
 func (p *Provider) readPublicKey(path string) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf("ssh public key path is empty")
@@ -321,24 +491,48 @@ func (p *Provider) readPublicKey(path string) (string, error) {
 	return string(content), nil
 }
 
+// awsConfig loads the SDK configuration for a region, using the profile's AWS profile if set.
+func (p *Provider) awsConfig(ctx context.Context, region string) (aws.Config, error) {
+	opts := []func(*awscfg.LoadOptions) error{awscfg.WithRegion(region)}
+	if p.cfg.AWS.Profile != "" {
+		opts = append(opts, awscfg.WithSharedConfigProfile(p.cfg.AWS.Profile))
+	}
+	cfg, err := awscfg.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load aws config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ec2Client creates an EC2 API client for the profile's region.
+func (p *Provider) ec2Client(ctx context.Context) (*awsec2.Client, error) {
+	cfg, err := p.awsConfig(ctx, p.cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return awsec2.NewFromConfig(cfg), nil
+}
+
 // GetInstanceStatus uses AWS SDK to fetch real-time info
 func (p *Provider) GetInstanceStatus(ctx context.Context, instanceID string) (*providers.RuntimeInfo, error) {
-	cfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(p.cfg.Region))
+	client, err := p.ec2Client(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load aws config: %w", err)
+		return nil, err
 	}
 
-	client := awsec2.NewFromConfig(cfg)
-
 	resp, err := client.DescribeInstances(ctx, &awsec2.DescribeInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidInstanceID.NotFound" {
+		return nil, fmt.Errorf("%s: %w", instanceID, providers.ErrInstanceNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if len(resp.Reservations) == 0 || len(resp.Reservations[0].Instances) == 0 {
-		return nil, fmt.Errorf("instance not found")
+		return nil, fmt.Errorf("%s: %w", instanceID, providers.ErrInstanceNotFound)
 	}
 
 	inst := resp.Reservations[0].Instances[0]
@@ -349,15 +543,88 @@ func (p *Provider) GetInstanceStatus(ctx context.Context, instanceID string) (*p
 		ip = *inst.PublicIpAddress
 	}
 
+	lifecycle := "on-demand"
+	if inst.InstanceLifecycle != "" {
+		lifecycle = string(inst.InstanceLifecycle)
+	}
+
+	hibernation := inst.HibernationOptions != nil && aws.ToBool(inst.HibernationOptions.Configured)
+
+	zone := ""
+	if inst.Placement != nil && inst.Placement.AvailabilityZone != nil {
+		zone = *inst.Placement.AvailabilityZone
+	}
+
+	var launchTime time.Time
+	if inst.LaunchTime != nil {
+		launchTime = *inst.LaunchTime
+	}
+
 	return &providers.RuntimeInfo{
 		ID:       instanceID,
 		PublicIP: ip,
 		State:    state,
 		// CPUUsage requires CloudWatch, skipping for MVP
-		CPUUsage: 0.0,
+		CPUUsage:         0.0,
+		InstanceType:     string(inst.InstanceType),
+		Lifecycle:        lifecycle,
+		Hibernation:      hibernation,
+		RootDevice:       aws.ToString(inst.RootDeviceName),
+		AvailabilityZone: zone,
+		LaunchTime:       launchTime,
 	}, nil
 }
 
+// InstanceReady reports whether both EC2 status checks (system and instance) pass.
+func (p *Provider) InstanceReady(ctx context.Context, instanceID string) (bool, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.DescribeInstanceStatus(ctx, &awsec2.DescribeInstanceStatusInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Status is only reported for running instances
+	if len(resp.InstanceStatuses) == 0 {
+		return false, nil
+	}
+
+	status := resp.InstanceStatuses[0]
+	if status.SystemStatus == nil || status.InstanceStatus == nil {
+		return false, nil
+	}
+	return status.SystemStatus.Status == ec2types.SummaryStatusOk &&
+		status.InstanceStatus.Status == ec2types.SummaryStatusOk, nil
+}
+
+// GetVolumes returns the EBS volumes attached to the instance.
+func (p *Provider) GetVolumes(ctx context.Context, instanceID string) ([]providers.VolumeInfo, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DescribeVolumes(ctx, &awsec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("attachment.instance-id"), Values: []string{instanceID}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := make([]providers.VolumeInfo, 0, len(resp.Volumes))
+	for _, v := range resp.Volumes {
+		volumes = append(volumes, volumeInfo(v))
+	}
+	return volumes, nil
+}
+
 // getPrincipalARN normalizes the caller ARN.
 // If it is an assumed-role ARN (STS), it converts it to the underlying IAM Role ARN.
 // This ensures the policy remains valid even after the session expires.
@@ -377,28 +644,25 @@ func (p *Provider) getPrincipalARN(arn string) string {
 
 // StartInstance starts the instance.
 func (p *Provider) StartInstance(ctx context.Context, instanceID string) error {
-	cfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(p.cfg.Region))
+	client, err := p.ec2Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to load aws config: %w", err)
+		return err
 	}
-
-	client := awsec2.NewFromConfig(cfg)
 	_, err = client.StartInstances(ctx, &awsec2.StartInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
-	return err
+	return wrapCapacityError(err)
 }
 
-// StopInstance stops the instance.
-func (p *Provider) StopInstance(ctx context.Context, instanceID string) error {
-	cfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(p.cfg.Region))
+// StopInstance stops (or hibernates) the instance.
+func (p *Provider) StopInstance(ctx context.Context, instanceID string, hibernate bool) error {
+	client, err := p.ec2Client(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to load aws config: %w", err)
+		return err
 	}
-
-	client := awsec2.NewFromConfig(cfg)
 	_, err = client.StopInstances(ctx, &awsec2.StopInstancesInput{
 		InstanceIds: []string{instanceID},
+		Hibernate:   &hibernate,
 	})
 	return err
 }
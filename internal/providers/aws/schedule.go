@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"privatebox/internal/providers"
+	"privatebox/internal/schedule"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/iam"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/scheduler"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// resolveSchedule returns the instance's start/stop schedule, or nil if none is configured.
+// A schedule on the spec (set at create time) takes precedence over the profile default.
+func (p *Provider) resolveSchedule(spec providers.InstanceSpec) (*schedule.Schedule, error) {
+	raw := spec.Schedule
+	if raw == "" {
+		raw = p.cfg.Schedule
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	return schedule.Parse(raw)
+}
+
+// newSchedulerRole creates the role EventBridge Scheduler assumes to start and stop the instance.
+func newSchedulerRole(ctx *pulumi.Context, name string) (*iam.Role, error) {
+	return iam.NewRole(ctx, name+"-scheduler-role", &iam.RoleArgs{
+		AssumeRolePolicy: pulumi.String(`{
+			"Version": "2012-10-17",
+			"Statement": [{
+				"Action": "sts:AssumeRole",
+				"Principal": {
+					"Service": "scheduler.amazonaws.com"
+				},
+				"Effect": "Allow",
+				"Sid": ""
+			}]
+		}`),
+		Tags: pulumi.StringMap{
+			"Name": pulumi.String(name + "-scheduler-role"),
+		},
+	})
+}
+
+// createSchedules grants the scheduler role permission to start/stop the instance and
+// creates one EventBridge Scheduler schedule per transition.
+func createSchedules(ctx *pulumi.Context, name string, sched *schedule.Schedule, role *iam.Role, srv *ec2.Instance) error {
+	policy := srv.Arn.ApplyT(func(arn string) string {
+		return fmt.Sprintf(`{
+			"Version": "2012-10-17",
+			"Statement": [{
+				"Effect": "Allow",
+				"Action": ["ec2:StartInstances", "ec2:StopInstances"],
+				"Resource": "%s"
+			}]
+		}`, arn)
+	}).(pulumi.StringOutput)
+
+	_, err := iam.NewRolePolicy(ctx, name+"-scheduler-policy", &iam.RolePolicyArgs{
+		Role:   role.ID(),
+		Policy: policy,
+	})
+	if err != nil {
+		return err
+	}
+
+	input := srv.ID().ApplyT(func(id pulumi.ID) (string, error) {
+		data, err := json.Marshal(map[string][]string{"InstanceIds": {string(id)}})
+		return string(data), err
+	}).(pulumi.StringOutput)
+
+	targets := map[schedule.Action]string{
+		schedule.Start: "arn:aws:scheduler:::aws-sdk:ec2:startInstances",
+		schedule.Stop:  "arn:aws:scheduler:::aws-sdk:ec2:stopInstances",
+	}
+	for _, action := range []schedule.Action{schedule.Start, schedule.Stop} {
+		_, err := scheduler.NewSchedule(ctx, fmt.Sprintf("%s-%s", name, action), &scheduler.ScheduleArgs{
+			Description: pulumi.String(fmt.Sprintf("privatebox: %s %s (%s)", action, name, sched)),
+			FlexibleTimeWindow: &scheduler.ScheduleFlexibleTimeWindowArgs{
+				Mode: pulumi.String("OFF"),
+			},
+			ScheduleExpression:         pulumi.String(sched.Cron(action)),
+			ScheduleExpressionTimezone: pulumi.String(sched.Location.String()),
+			Target: &scheduler.ScheduleTargetArgs{
+				Arn:     pulumi.String(targets[action]),
+				RoleArn: role.Arn,
+				Input:   input,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// capacityErrorCodes are the EC2 error codes that mean "try again later or elsewhere".
+var capacityErrorCodes = map[string]bool{
+	"InsufficientInstanceCapacity": true,
+	"InsufficientCapacity":         true,
+	"SpotMaxPriceTooLow":           true,
+}
+
+// spotMarketOptions returns the market options for a persistent spot instance,
+// or nil for on-demand instances.
+func spotMarketOptions(spot *config.SpotConfig) ec2.InstanceInstanceMarketOptionsPtrInput {
+	if spot == nil || !spot.Enabled {
+		return nil
+	}
+
+	behavior := spot.InterruptionBehavior
+	if behavior == "" {
+		behavior = "stop"
+	}
+
+	opts := &ec2.InstanceInstanceMarketOptionsSpotOptionsArgs{
+		// Persistent requests are required for interruption behaviors other than
+		// terminate, and let the instance be stopped and started like on-demand ones.
+		SpotInstanceType:             pulumi.String("persistent"),
+		InstanceInterruptionBehavior: pulumi.String(behavior),
+	}
+	if spot.MaxPrice != "" {
+		opts.MaxPrice = pulumi.String(spot.MaxPrice)
+	}
+
+	return &ec2.InstanceInstanceMarketOptionsArgs{
+		MarketType:  pulumi.String("spot"),
+		SpotOptions: opts,
+	}
+}
+
+// wrapCapacityError marks EC2 capacity errors with providers.ErrInsufficientCapacity.
+func wrapCapacityError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && capacityErrorCodes[apiErr.ErrorCode()] {
+		return fmt.Errorf("%w: %w", providers.ErrInsufficientCapacity, err)
+	}
+	return err
+}
+
+// GetSpotPrice returns the most recent Linux spot price for the instance type in the zone.
+func (p *Provider) GetSpotPrice(ctx context.Context, instanceType, zone string) (float64, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.DescribeSpotPriceHistory(ctx, &awsec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:       []ec2types.InstanceType{ec2types.InstanceType(instanceType)},
+		AvailabilityZone:    aws.String(zone),
+		ProductDescriptions: []string{"Linux/UNIX"},
+		StartTime:           aws.Time(time.Now()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.SpotPriceHistory) == 0 || resp.SpotPriceHistory[0].SpotPrice == nil {
+		return 0, fmt.Errorf("no spot price for %s in %s", instanceType, zone)
+	}
+	return strconv.ParseFloat(*resp.SpotPriceHistory[0].SpotPrice, 64)
+}
+
+// SimilarInstanceTypes returns current-generation instance types with the same
+// vCPU count, memory and architecture as instanceType.
+func (p *Provider) SimilarInstanceTypes(ctx context.Context, instanceType string) ([]string, error) {
+	client, err := p.ec2Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DescribeInstanceTypes(ctx, &awsec2.DescribeInstanceTypesInput{
+		InstanceTypes: []ec2types.InstanceType{ec2types.InstanceType(instanceType)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.InstanceTypes) == 0 {
+		return nil, fmt.Errorf("unknown instance type %s", instanceType)
+	}
+
+	info := resp.InstanceTypes[0]
+	if info.VCpuInfo == nil || info.MemoryInfo == nil || info.ProcessorInfo == nil {
+		return nil, fmt.Errorf("incomplete instance type info for %s", instanceType)
+	}
+
+	filters := []ec2types.Filter{
+		{Name: aws.String("current-generation"), Values: []string{"true"}},
+		{Name: aws.String("vcpu-info.default-vcpus"), Values: []string{strconv.Itoa(int(aws.ToInt32(info.VCpuInfo.DefaultVCpus)))}},
+		{Name: aws.String("memory-info.size-in-mib"), Values: []string{strconv.FormatInt(aws.ToInt64(info.MemoryInfo.SizeInMiB), 10)}},
+	}
+	// Values of one filter are ORed, separate filters ANDed
+	archs := make([]string, 0, len(info.ProcessorInfo.SupportedArchitectures))
+	for _, arch := range info.ProcessorInfo.SupportedArchitectures {
+		archs = append(archs, string(arch))
+	}
+	if len(archs) > 0 {
+		filters = append(filters, ec2types.Filter{Name: aws.String("processor-info.supported-architecture"), Values: archs})
+	}
+
+	var similar []string
+	paginator := awsec2.NewDescribeInstanceTypesPaginator(client, &awsec2.DescribeInstanceTypesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.InstanceTypes {
+			if string(t.InstanceType) != instanceType {
+				similar = append(similar, string(t.InstanceType))
+			}
+		}
+	}
+	sort.Strings(similar)
+	return similar, nil
+}
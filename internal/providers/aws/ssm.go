@@ -0,0 +1,164 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsssm "github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ssmEndpointServices are the interface endpoints the SSM agent needs to work
+// without internet access.
+var ssmEndpointServices = []string{"ssm", "ssmmessages", "ec2messages"}
+
+// ssmClient creates an SSM API client for the profile's region.
+func (p *Provider) ssmClient(ctx context.Context) (*awsssm.Client, error) {
+	cfg, err := p.awsConfig(ctx, p.cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return awsssm.NewFromConfig(cfg), nil
+}
+
+// SessionReady reports whether the instance's SSM agent is registered and online.
+func (p *Provider) SessionReady(ctx context.Context, instanceID string) (bool, error) {
+	client, err := p.ssmClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.DescribeInstanceInformation(ctx, &awsssm.DescribeInstanceInformationInput{
+		Filters: []ssmtypes.InstanceInformationStringFilter{
+			{Key: aws.String("InstanceIds"), Values: []string{instanceID}},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// The agent registers a while after boot
+	if len(resp.InstanceInformationList) == 0 {
+		return false, nil
+	}
+	return resp.InstanceInformationList[0].PingStatus == ssmtypes.PingStatusOnline, nil
+}
+
+// commandPollInterval is how often RunCommand checks whether a command has finished.
+const commandPollInterval = 2 * time.Second
+
+// RunCommand runs a shell command with the AWS-RunShellScript document. SSM keeps
+// only the first 24,000 characters of each output stream.
+func (p *Provider) RunCommand(ctx context.Context, instanceID, command string) (*providers.CommandResult, error) {
+	client, err := p.ssmClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := client.SendCommand(ctx, &awsssm.SendCommandInput{
+		DocumentName: aws.String("AWS-RunShellScript"),
+		InstanceIds:  []string{instanceID},
+		Parameters:   map[string][]string{"commands": {command}},
+		Comment:      aws.String("privatebox exec"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	commandID := aws.ToString(sent.Command.CommandId)
+
+	ticker := time.NewTicker(commandPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		inv, err := client.GetCommandInvocation(ctx, &awsssm.GetCommandInvocationInput{
+			CommandId:  aws.String(commandID),
+			InstanceId: aws.String(instanceID),
+		})
+		var notYet *ssmtypes.InvocationDoesNotExist
+		if errors.As(err, &notYet) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch inv.Status {
+		case ssmtypes.CommandInvocationStatusPending, ssmtypes.CommandInvocationStatusInProgress,
+			ssmtypes.CommandInvocationStatusDelayed:
+			continue
+		case ssmtypes.CommandInvocationStatusSuccess, ssmtypes.CommandInvocationStatusFailed:
+			return &providers.CommandResult{
+				ExitCode: int(inv.ResponseCode),
+				Stdout:   aws.ToString(inv.StandardOutputContent),
+				Stderr:   aws.ToString(inv.StandardErrorContent),
+			}, nil
+		default:
+			return nil, fmt.Errorf("command %s %s: %s", commandID, strings.ToLower(string(inv.Status)), aws.ToString(inv.StatusDetails))
+		}
+	}
+}
+
+// createSSMEndpoints adds the interface endpoints of SSM to a dedicated network, so
+// that instances without a public IP can be reached through Session Manager. They
+// are placed in a single subnet; the other zones reach them through the VPC.
+func createSSMEndpoints(ctx *pulumi.Context, name, region, cidr string, vpc *ec2.Vpc, subnet *ec2.Subnet, tags pulumi.StringMap) error {
+	sg, err := ec2.NewSecurityGroup(ctx, name+"-ssm-endpoints", &ec2.SecurityGroupArgs{
+		Description: pulumi.String("SSM endpoints of " + name),
+		VpcId:       vpc.ID(),
+		Ingress: ec2.SecurityGroupIngressArray{
+			&ec2.SecurityGroupIngressArgs{
+				Protocol:   pulumi.String("tcp"),
+				FromPort:   pulumi.Int(443),
+				ToPort:     pulumi.Int(443),
+				CidrBlocks: pulumi.StringArray{pulumi.String(cidr)},
+			},
+		},
+		Tags: tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, service := range ssmEndpointServices {
+		_, err := ec2.NewVpcEndpoint(ctx, fmt.Sprintf("%s-%s", name, service), &ec2.VpcEndpointArgs{
+			VpcId:             vpc.ID(),
+			ServiceName:       pulumi.String(fmt.Sprintf("com.amazonaws.%s.%s", region, service)),
+			VpcEndpointType:   pulumi.String("Interface"),
+			PrivateDnsEnabled: pulumi.Bool(true),
+			SubnetIds:         pulumi.StringArray{subnet.ID()},
+			SecurityGroupIds:  pulumi.StringArray{sg.ID()},
+			Tags:              tags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usesSSM reports whether the instance is reached through Session Manager.
+func usesSSM(spec providers.InstanceSpec) bool {
+	return spec.ConnectMode == config.ConnectModeSSM
+}
+
+// connectMode returns the spec's connect mode, defaulting to SSH.
+func connectMode(spec providers.InstanceSpec) string {
+	if usesSSM(spec) {
+		return config.ConnectModeSSM
+	}
+	return config.ConnectModeSSH
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// metricPeriod is the resolution of EC2's basic monitoring metrics.
+const metricPeriod = 5 * time.Minute
+
+// RunningHours returns how long the instance ran since the given time. EC2 only
+// publishes metrics while an instance runs, so every 5-minute period with a
+// CPUUtilization sample counts as running. This includes starts by schedules, the
+// console or other tools, and stops from inside the instance.
+func (p *Provider) RunningHours(ctx context.Context, instanceID string, since time.Time) (float64, error) {
+	cfg, err := p.awsConfig(ctx, p.cfg.Region)
+	if err != nil {
+		return 0, err
+	}
+	client := cloudwatch.NewFromConfig(cfg)
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(since.Truncate(metricPeriod)),
+		EndTime:   aws.Time(time.Now()),
+		MetricDataQueries: []cwtypes.MetricDataQuery{{
+			Id: aws.String("running"),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/EC2"),
+					MetricName: aws.String("CPUUtilization"),
+					Dimensions: []cwtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(instanceID)}},
+				},
+				Period: aws.Int32(int32(metricPeriod.Seconds())),
+				Stat:   aws.String("SampleCount"),
+			},
+		}},
+	}
+
+	periods := 0
+	paginator := cloudwatch.NewGetMetricDataPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read metrics of %s: %w", instanceID, err)
+		}
+		for _, result := range page.MetricDataResults {
+			periods += len(result.Values)
+		}
+	}
+	return float64(periods) * metricPeriod.Hours(), nil
+}
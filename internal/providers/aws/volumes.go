@@ -0,0 +1,172 @@
+package aws
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"privatebox/internal/config"
+	"privatebox/internal/providers"
+	"privatebox/internal/userdata"
+
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ebs"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/kms"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const defaultVolumeType = "gp3"
+
+// maxDataVolumes is the number of device names available for data volumes (/dev/sdf-/dev/sdo,
+// /dev/sdp is reserved for the persistent volume).
+const maxDataVolumes = 10
+
+// dataVolumeDevice returns the device name the i-th data volume is attached as.
+func dataVolumeDevice(i int) string {
+	return fmt.Sprintf("/dev/sd%c", 'f'+i)
+}
+
+// validateVolumes checks the spec's volumes before any resource is created.
+func validateVolumes(spec providers.InstanceSpec) error {
+	if len(spec.DataVolumes) > maxDataVolumes {
+		return fmt.Errorf("at most %d data volumes are supported", maxDataVolumes)
+	}
+	seen := map[string]bool{}
+	for _, v := range spec.DataVolumes {
+		if !path.IsAbs(v.MountPoint) || path.Clean(v.MountPoint) == "/" {
+			return fmt.Errorf("invalid data volume mount point %q", v.MountPoint)
+		}
+		if seen[path.Clean(v.MountPoint)] {
+			return fmt.Errorf("duplicate data volume mount point %q", v.MountPoint)
+		}
+		seen[path.Clean(v.MountPoint)] = true
+		if v.SizeGiB <= 0 {
+			return fmt.Errorf("data volume %s: size is required", v.MountPoint)
+		}
+	}
+	if home := spec.HomeVolume; home != nil && seen[path.Clean(home.MountPoint)] {
+		return fmt.Errorf("data volume mount point %s is used by persistent volume %s", home.MountPoint, home.Name)
+	}
+	return nil
+}
+
+// rootBlockDevice returns the root volume settings, encrypted with the instance's key.
+func rootBlockDevice(spec providers.InstanceSpec, key *kms.Key) *ec2.InstanceRootBlockDeviceArgs {
+	args := &ec2.InstanceRootBlockDeviceArgs{
+		VolumeType:          pulumi.String(defaultVolumeType),
+		Encrypted:           pulumi.Bool(true),
+		KmsKeyId:            key.Arn,
+		DeleteOnTermination: pulumi.Bool(true),
+	}
+
+	root := spec.RootVolume
+	if root == nil {
+		return args
+	}
+	if root.Type != "" {
+		args.VolumeType = pulumi.String(root.Type)
+	}
+	if root.SizeGiB > 0 {
+		args.VolumeSize = pulumi.Int(root.SizeGiB)
+	}
+	if root.IOPS > 0 {
+		args.Iops = pulumi.Int(root.IOPS)
+	}
+	if root.Throughput > 0 {
+		args.Throughput = pulumi.Int(root.Throughput)
+	}
+	return args
+}
+
+// dataVolumeMounts returns the devices the user data formats and mounts, including the
+// persistent volume. It only depends on the mount points, so resizing a volume does not
+// change the user data.
+func dataVolumeMounts(spec providers.InstanceSpec) []userdata.Mount {
+	mounts := make([]userdata.Mount, 0, len(spec.DataVolumes)+1)
+	for i, v := range spec.DataVolumes {
+		mounts = append(mounts, userdata.Mount{Device: dataVolumeDevice(i), MountPoint: v.MountPoint})
+	}
+	if home := spec.HomeVolume; home != nil {
+		mounts = append(mounts, userdata.Mount{Device: homeVolumeDevice, MountPoint: home.MountPoint})
+	}
+	return mounts
+}
+
+// createDataVolumes creates the spec's data volumes in the instance's zone and attaches them.
+// Volumes are separate resources (rather than block devices of the instance) so that
+// they can be resized in place.
+func createDataVolumes(ctx *pulumi.Context, spec providers.InstanceSpec, key *kms.Key, srv *ec2.Instance) error {
+	for i, v := range spec.DataVolumes {
+		resName := spec.Name + "-vol-" + volumeResourceSuffix(v)
+
+		volumeType := v.Type
+		if volumeType == "" {
+			volumeType = defaultVolumeType
+		}
+		args := &ebs.VolumeArgs{
+			AvailabilityZone: srv.AvailabilityZone,
+			Size:             pulumi.Int(v.SizeGiB),
+			Type:             pulumi.String(volumeType),
+			Encrypted:        pulumi.Bool(true),
+			KmsKeyId:         key.Arn,
+			Tags: pulumi.StringMap{
+				"Name":       pulumi.String(spec.Name + ":" + v.MountPoint),
+				"MountPoint": pulumi.String(v.MountPoint),
+			},
+		}
+		if v.IOPS > 0 {
+			args.Iops = pulumi.Int(v.IOPS)
+		}
+		if v.Throughput > 0 {
+			args.Throughput = pulumi.Int(v.Throughput)
+		}
+
+		var opts []pulumi.ResourceOption
+		if !deleteOnTermination(v) {
+			// Keep the volume (and its data) when the instance is destroyed
+			opts = append(opts, pulumi.RetainOnDelete(true))
+		}
+
+		vol, err := ebs.NewVolume(ctx, resName, args, opts...)
+		if err != nil {
+			return err
+		}
+
+		_, err = ec2.NewVolumeAttachment(ctx, resName+"-attachment", &ec2.VolumeAttachmentArgs{
+			DeviceName:                  pulumi.String(dataVolumeDevice(i)),
+			InstanceId:                  srv.ID(),
+			VolumeId:                    vol.ID(),
+			StopInstanceBeforeDetaching: pulumi.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// volumeResourceSuffix derives a stable resource name from the mount point, so that
+// removing one volume does not recreate the volumes of the others. Device names still
+// follow the order of the volumes: the attachments of the volumes after a removed one
+// are replaced (they are detached and attached as another device).
+func volumeResourceSuffix(v config.DataVolume) string {
+	name := strings.Trim(strings.ReplaceAll(path.Clean(v.MountPoint), "/", "-"), "-")
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+func deleteOnTermination(v config.DataVolume) bool {
+	return v.DeleteOnTermination == nil || *v.DeleteOnTermination
+}
+
+// retainsDataVolumes reports whether any data volume is kept when the instance is destroyed.
+func retainsDataVolumes(spec providers.InstanceSpec) bool {
+	for _, v := range spec.DataVolumes {
+		if !deleteOnTermination(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,26 +3,182 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"privatebox/internal/config"
+	"privatebox/internal/pricing"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // InstanceSpec defines the desired state of an instance.
+// It is stored with the instance (see SpecOutput) so that later updates, e.g.
+// resizing a volume, re-apply the same settings.
 type InstanceSpec struct {
+	Name         string                     `json:"name"`
+	Type         string                     `json:"type,omitempty"`           // e.g. "t3.micro"
+	AMI          string                     `json:"ami,omitempty"`            // Image resolved at create time; empty for instances created by older versions
+	SSHUser      string                     `json:"ssh_user,omitempty"`       // Login user of the image
+	ProfileName  string                     `json:"profile_name,omitempty"`   // Profile used to create the instance
+	UserData     string                     `json:"user_data,omitempty"`      // Cloud-init script or similar
+	UserDataName string                     `json:"user_data_name,omitempty"` // Name of the managed userdata script (optional)
+	Schedule     string                     `json:"schedule,omitempty"`       // Start/stop window (optional, overrides the profile's schedule)
+	RootVolume   *config.RootVolume         `json:"root_volume,omitempty"`
+	DataVolumes  []config.DataVolume        `json:"data_volumes,omitempty"`
+	HomeVolume   *HomeVolume                `json:"home_volume,omitempty"`   // Persistent volume to attach (optional)
+	Network      *Network                   `json:"network,omitempty"`       // Subnet to launch in (nil: default VPC)
+	ConnectMode  string                     `json:"connect_mode,omitempty"`  // config.ConnectModeSSH (default) or config.ConnectModeSSM
+	IngressRules []config.SecurityGroupRule `json:"ingress_rules"`           // Firewall rules; nil for instances created by older versions
+	MyIP         string                     `json:"my_ip,omitempty"`         // What config.MyIP resolved to at the last update
+	DNS          *DNSRecord                 `json:"dns,omitempty"`           // DNS record following the public IP (optional)
+	ElasticIP    bool                       `json:"elastic_ip,omitempty"`    // Allocate a fixed public address
+	Hibernation  *bool                      `json:"hibernation,omitempty"`   // Launch with hibernation support; nil for instances created by older versions
+	Spot         *config.SpotConfig         `json:"spot,omitempty"`          // Spot settings at launch; nil for instances created by older versions
+	IdleShutdown *config.IdleShutdown       `json:"idle_shutdown,omitempty"` // Idle policy (empty After: none); nil for instances created by older versions
+	Tags         map[string]string          `json:"tags,omitempty"`          // Resource tags
+}
+
+// HomeVolume is a persistent volume (see VolumeSpec) attached to an instance.
+type HomeVolume struct {
+	Name             string `json:"name"`
+	VolumeID         string `json:"volume_id"`
+	AvailabilityZone string `json:"availability_zone"` // The instance is placed in the volume's zone
+	KeyARN           string `json:"key_arn"`
+	MountPoint       string `json:"mount_point"`
+}
+
+// Image is an operating system image instances can be launched from.
+type Image struct {
+	ID           string
 	Name         string
-	Type         string            // e.g. "t3.micro"
-	ProfileName  string            // Profile used to create the instance
-	UserData     string            // Cloud-init script or similar
-	UserDataName string            // Name of the managed userdata script (optional)
-	Tags         map[string]string // Resource tags
+	OwnerID      string
+	Platform     string // Platform details, e.g. "Linux/UNIX" or "Red Hat Enterprise Linux"
+	Architecture string // e.g. "x86_64", "arm64"
+	CreationDate string
+	RootSizeGiB  int // Size of the image's root volume
+}
+
+// InstanceType describes an instance type.
+type InstanceType struct {
+	Name          string
+	VCPUs         int
+	MemoryMiB     int64
+	Architectures []string // e.g. ["x86_64"], ["arm64"]
+	Zones         []string // Availability zones of the region offering the type
+}
+
+// ImageQuery selects an image: by ID, or the latest release of a catalog alias
+// (see package images) for an architecture.
+type ImageQuery struct {
+	ID           string
+	Alias        string // default: images.DefaultImage
+	Architecture string // default: x86_64
+}
+
+// DNSRecord is an instance's DNS name.
+type DNSRecord struct {
+	ZoneID string `json:"zone_id"`
+	Name   string `json:"name"` // e.g. "web.dev.example.com"
+	TTL    int    `json:"ttl"`
+}
+
+// Network is the VPC and subnet an instance is launched in.
+type Network struct {
+	VPCID             string `json:"vpc_id"`
+	SubnetID          string `json:"subnet_id"`
+	AvailabilityZone  string `json:"availability_zone"`
+	AssociatePublicIP bool   `json:"associate_public_ip"`
+}
+
+// SubnetQuery selects a subnet. An empty query selects a subnet of the default VPC.
+type SubnetQuery struct {
+	VPCID             string
+	SubnetID          string
+	Tags              map[string]string
+	AvailabilityZones []string // Allowed zones (optional)
+}
+
+// NetworkSpec defines a dedicated network (VPC with public subnets) for a profile.
+type NetworkSpec struct {
+	Name         string // Name of the profile
+	CIDR         string
+	SSMEndpoints bool // Add VPC endpoints so instances without a public IP can reach SSM
+}
+
+// VolumeSpec defines a persistent volume. It lives in its own stack, with its own
+// encryption key, and outlives the instances it is attached to.
+type VolumeSpec struct {
+	Name        string
+	ProfileName string
+	Volume      config.PersistentVolume
+}
+
+// SpecOutput is the name of the stack output holding the JSON encoded InstanceSpec.
+const SpecOutput = "spec"
+
+// ErrNoSpec is returned by ParseSpec for instances created before specs were stored.
+var ErrNoSpec = errors.New("instance has no stored spec (it was created by an older version of privatebox)")
+
+// JSON returns the spec in the format stored in SpecOutput.
+func (s InstanceSpec) JSON() string {
+	// Marshal cannot fail, the spec only contains plain data types
+	data, _ := json.Marshal(s)
+	return string(data)
 }
 
+// ParseSpec decodes a spec stored in SpecOutput.
+func ParseSpec(value string) (InstanceSpec, error) {
+	var spec InstanceSpec
+	if value == "" {
+		return spec, ErrNoSpec
+	}
+	if err := json.Unmarshal([]byte(value), &spec); err != nil {
+		return spec, fmt.Errorf("invalid instance spec: %w", err)
+	}
+	return spec, nil
+}
+
+// ErrInsufficientCapacity is returned (wrapped) when the provider has no capacity
+// for the requested instance type, e.g. when a spot instance cannot be started.
+var ErrInsufficientCapacity = errors.New("insufficient capacity")
+
+// ErrInstanceNotFound is returned (wrapped) when the provider does not know the
+// instance, e.g. right after it was launched, before it is visible to every API.
+var ErrInstanceNotFound = errors.New("instance not found")
+
 // RuntimeInfo contains status data fetched from the cloud provider.
 type RuntimeInfo struct {
-	ID       string
-	PublicIP string
-	State    string
-	CPUUsage float64
+	ID               string
+	PublicIP         string
+	State            string
+	CPUUsage         float64
+	InstanceType     string
+	Lifecycle        string // "on-demand" or "spot"
+	Hibernation      bool   // Launched with hibernation enabled
+	RootDevice       string // Device name of the root volume, e.g. "/dev/xvda"
+	AvailabilityZone string
+	LaunchTime       time.Time // Time of the most recent start
+}
+
+// VolumeInfo describes a block storage volume attached to an instance.
+type VolumeInfo struct {
+	ID        string
+	Device    string
+	Type      string // e.g. "gp3"
+	SizeGiB   int
+	State     string // e.g. "available", "in-use"
+	Attached  string // ID of the instance the volume is attached to
+	CreatedAt time.Time
+}
+
+// CommandResult is the outcome of a command run on an instance.
+type CommandResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
 }
 
 // CloudProvider defines the contract for any cloud backend (AWS, GCP, etc).
@@ -33,15 +189,67 @@ type CloudProvider interface {
 	// GetPulumiProgram returns the logic to run inside the Pulumi engine.
 	GetPulumiProgram(spec InstanceSpec) pulumi.RunFunc
 
-	// GetSSHUser returns the default username for SSH connections (e.g. "ubuntu").
+	// GetSSHUser returns the default username for SSH connections (e.g. "ubuntu"),
+	// used for instances whose image user is unknown.
 	GetSSHUser() string
 
+	// ResolveImage returns the image matching the query.
+	ResolveImage(ctx context.Context, query ImageQuery) (*Image, error)
+
 	// GetInstanceStatus fetches real-time data from the cloud API (outside Pulumi state).
 	GetInstanceStatus(ctx context.Context, instanceID string) (*RuntimeInfo, error)
 
+	// InstanceReady reports whether the instance has passed the provider's health checks.
+	InstanceReady(ctx context.Context, instanceID string) (bool, error)
+
+	// SessionReady reports whether the instance's agent accepts SSM sessions.
+	SessionReady(ctx context.Context, instanceID string) (bool, error)
+
+	// RunCommand runs a shell command on the instance through the provider's agent
+	// (without SSH) and waits for it to finish.
+	RunCommand(ctx context.Context, instanceID, command string) (*CommandResult, error)
+
+	// UpdateDNSRecord points the record at a new address.
+	UpdateDNSRecord(ctx context.Context, record DNSRecord, ip string) error
+
+	// RunningHours returns how long the instance ran since the given time.
+	RunningHours(ctx context.Context, instanceID string, since time.Time) (float64, error)
+
+	// GetSpotPrice returns the current spot price (USD/hour) of an instance type in a zone.
+	GetSpotPrice(ctx context.Context, instanceType, zone string) (float64, error)
+
+	// DescribeInstanceType returns an instance type, or an error if the region doesn't offer it.
+	DescribeInstanceType(ctx context.Context, instanceType string) (*InstanceType, error)
+
+	// ListInstanceTypes returns the current-generation instance types offered in the
+	// region, optionally only those supporting an architecture.
+	ListInstanceTypes(ctx context.Context, arch string) ([]InstanceType, error)
+
+	// SimilarInstanceTypes returns alternative instance types with the same size and architecture.
+	SimilarInstanceTypes(ctx context.Context, instanceType string) ([]string, error)
+
+	// GetVolumeProgram returns the Pulumi program of a persistent volume.
+	GetVolumeProgram(spec VolumeSpec) pulumi.RunFunc
+
+	// GetNetworkProgram returns the Pulumi program of a profile's dedicated network.
+	GetNetworkProgram(spec NetworkSpec) pulumi.RunFunc
+
+	// FindSubnet returns the subnet matching the query with the most free addresses.
+	FindSubnet(ctx context.Context, query SubnetQuery) (*Network, error)
+
+	// GetVolumes returns the volumes attached to the instance.
+	GetVolumes(ctx context.Context, instanceID string) ([]VolumeInfo, error)
+
+	// GetVolume returns a single volume.
+	GetVolume(ctx context.Context, volumeID string) (*VolumeInfo, error)
+
+	// FetchPrices downloads current prices for the profile's region.
+	FetchPrices(ctx context.Context) (*pricing.RegionPrices, error)
+
 	// StartInstance starts a stopped instance.
 	StartInstance(ctx context.Context, instanceID string) error
 
-	// StopInstance stops a running instance.
-	StopInstance(ctx context.Context, instanceID string) error
+	// StopInstance stops a running instance. If hibernate is true, the instance
+	// is hibernated instead (it must have been launched with hibernation enabled).
+	StopInstance(ctx context.Context, instanceID string, hibernate bool) error
 }
@@ -0,0 +1,193 @@
+// Package schedule parses recurring start/stop windows such as
+// "weekdays 08:00-19:00 America/New_York".
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Action is the transition performed at a scheduled time.
+type Action string
+
+const (
+	// Start starts the instance.
+	Start Action = "start"
+	// Stop stops the instance.
+	Stop Action = "stop"
+)
+
+var dayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// Schedule is a weekly window during which an instance should be running.
+type Schedule struct {
+	Days     []time.Weekday
+	StartMin int // Minutes after midnight
+	StopMin  int // Minutes after midnight
+	Location *time.Location
+}
+
+// Parse parses a schedule of the form "<days> <HH:MM>-<HH:MM> [timezone]".
+// Days may be "daily", "weekdays", "weekends", a single day ("mon"),
+// a range ("mon-thu") or a comma separated list ("mon,wed,fri").
+// The timezone defaults to UTC.
+func Parse(s string) (*Schedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid schedule %q: expected \"<days> <HH:MM>-<HH:MM> [timezone]\"", s)
+	}
+
+	days, err := parseDays(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	startStr, stopStr, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid time window %q: expected HH:MM-HH:MM", fields[1])
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseClock(stopStr)
+	if err != nil {
+		return nil, err
+	}
+	if stop <= start {
+		return nil, fmt.Errorf("invalid time window %q: stop must be after start", fields[1])
+	}
+
+	loc := time.UTC
+	if len(fields) == 3 {
+		loc, err = time.LoadLocation(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", fields[2], err)
+		}
+	}
+
+	return &Schedule{Days: days, StartMin: start, StopMin: stop, Location: loc}, nil
+}
+
+func parseDays(s string) ([]time.Weekday, error) {
+	switch strings.ToLower(s) {
+	case "daily", "everyday":
+		return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}, nil
+	case "weekdays":
+		return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, nil
+	case "weekends":
+		return []time.Weekday{time.Sunday, time.Saturday}, nil
+	}
+
+	seen := map[time.Weekday]bool{}
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return nil, err
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			seen[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if seen[d] {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+func parseDay(s string) (time.Weekday, error) {
+	upper := strings.ToUpper(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if upper == dayNames[d] || upper == strings.ToUpper(d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day %q", s)
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// String returns the canonical form of the schedule.
+func (s *Schedule) String() string {
+	return fmt.Sprintf("%s %s-%s %s", strings.ToLower(s.dayList()), formatClock(s.StartMin), formatClock(s.StopMin), s.Location)
+}
+
+// Cron returns the EventBridge Scheduler cron expression for the given action,
+// to be evaluated in the schedule's timezone.
+func (s *Schedule) Cron(action Action) string {
+	minutes := s.StartMin
+	if action == Stop {
+		minutes = s.StopMin
+	}
+	return fmt.Sprintf("cron(%d %d ? * %s *)", minutes%60, minutes/60, s.dayList())
+}
+
+// Next returns the first transition strictly after now.
+func (s *Schedule) Next(now time.Time) (Action, time.Time) {
+	local := now.In(s.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Location)
+
+	// Each week contains at least one scheduled day, so eight days always yields a transition.
+	for offset := 0; offset <= 7; offset++ {
+		day := midnight.AddDate(0, 0, offset)
+		if !s.includes(day.Weekday()) {
+			continue
+		}
+		start := s.at(day, s.StartMin)
+		if start.After(now) {
+			return Start, start
+		}
+		stop := s.at(day, s.StopMin)
+		if stop.After(now) {
+			return Stop, stop
+		}
+	}
+	return "", time.Time{}
+}
+
+// at returns the wall-clock time on day, which stays correct across DST changes.
+func (s *Schedule) at(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, s.Location)
+}
+
+func (s *Schedule) includes(day time.Weekday) bool {
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) dayList() string {
+	names := make([]string, len(s.Days))
+	for i, d := range s.Days {
+		names[i] = dayNames[d]
+	}
+	return strings.Join(names, ",")
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantStr   string
+		wantStart string
+		wantStop  string
+		wantErr   bool
+	}{
+		{
+			name:      "Weekdays with timezone",
+			input:     "weekdays 08:00-19:00 America/New_York",
+			wantStr:   "mon,tue,wed,thu,fri 08:00-19:00 America/New_York",
+			wantStart: "cron(0 8 ? * MON,TUE,WED,THU,FRI *)",
+			wantStop:  "cron(0 19 ? * MON,TUE,WED,THU,FRI *)",
+		},
+		{
+			name:      "Day range defaults to UTC",
+			input:     "mon-wed 09:30-17:45",
+			wantStr:   "mon,tue,wed 09:30-17:45 UTC",
+			wantStart: "cron(30 9 ? * MON,TUE,WED *)",
+			wantStop:  "cron(45 17 ? * MON,TUE,WED *)",
+		},
+		{
+			name:      "Wrapping range and list",
+			input:     "fri-sun,wednesday 10:00-12:00",
+			wantStr:   "sun,wed,fri,sat 10:00-12:00 UTC",
+			wantStart: "cron(0 10 ? * SUN,WED,FRI,SAT *)",
+			wantStop:  "cron(0 12 ? * SUN,WED,FRI,SAT *)",
+		},
+		{name: "Missing window", input: "weekdays", wantErr: true},
+		{name: "Bad day", input: "funday 08:00-09:00", wantErr: true},
+		{name: "Stop before start", input: "daily 19:00-08:00", wantErr: true},
+		{name: "Bad timezone", input: "daily 08:00-09:00 Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.String(); got != tt.wantStr {
+				t.Errorf("String() = %q, want %q", got, tt.wantStr)
+			}
+			if got := s.Cron(Start); got != tt.wantStart {
+				t.Errorf("Cron(Start) = %q, want %q", got, tt.wantStart)
+			}
+			if got := s.Cron(Stop); got != tt.wantStop {
+				t.Errorf("Cron(Stop) = %q, want %q", got, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	s, err := Parse("weekdays 08:00-19:00 UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantAction Action
+		wantTime   time.Time
+	}{
+		{
+			name:       "Before start on a weekday",
+			now:        time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC), // Monday
+			wantAction: Start,
+			wantTime:   time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "During window",
+			now:        time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC),
+			wantAction: Stop,
+			wantTime:   time.Date(2024, 6, 3, 19, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "Friday evening skips the weekend",
+			now:        time.Date(2024, 6, 7, 20, 0, 0, 0, time.UTC),
+			wantAction: Start,
+			wantTime:   time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, at := s.Next(tt.now)
+			if action != tt.wantAction || !at.Equal(tt.wantTime) {
+				t.Errorf("Next() = %s %v, want %s %v", action, at, tt.wantAction, tt.wantTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package sshconfig generates the ssh client configuration for instances.
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HostPrefix is prepended to instance names to form their ssh host aliases.
+const HostPrefix = "privatebox-"
+
+const header = "# Managed by privatebox (privatebox ssh-config). Changes are overwritten.\n"
+
+// Host is the ssh configuration of one instance.
+type Host struct {
+	Name         string // Instance name; the alias is HostPrefix + Name
+	HostName     string // Address to connect to, empty if the instance has none (e.g. stopped)
+	User         string
+	IdentityFile string // optional
+	ProxyCommand string // optional, e.g. for SSM
+}
+
+// Alias returns the ssh host alias of the instance.
+func (h Host) Alias() string {
+	return HostPrefix + h.Name
+}
+
+// Render returns the configuration file for the hosts, sorted by name.
+func Render(hosts []Host) string {
+	sorted := append([]Host{}, hosts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	b.WriteString(header)
+	for _, h := range sorted {
+		b.WriteString("\n")
+		if h.HostName == "" {
+			fmt.Fprintf(&b, "# %s has no address (stopped?)\n", h.Alias())
+			continue
+		}
+		fmt.Fprintf(&b, "Host %s\n", h.Alias())
+		fmt.Fprintf(&b, "  HostName %s\n", h.HostName)
+		fmt.Fprintf(&b, "  User %s\n", h.User)
+		// Public IPs change on every start; keep one known_hosts entry per instance
+		fmt.Fprintf(&b, "  HostKeyAlias %s\n", h.Alias())
+		if h.IdentityFile != "" {
+			fmt.Fprintf(&b, "  IdentityFile %s\n", quote(h.IdentityFile))
+			b.WriteString("  IdentitiesOnly yes\n")
+		}
+		if h.ProxyCommand != "" {
+			fmt.Fprintf(&b, "  ProxyCommand %s\n", h.ProxyCommand)
+		}
+	}
+	return b.String()
+}
+
+// quote quotes values containing spaces.
+func quote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// Write replaces the file at path with the configuration for the hosts.
+func Write(path string, hosts []Host) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(Render(hosts)), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// EnsureInclude adds an Include of includePath to the top of the ssh config at
+// configPath (Includes only apply before the first Host block). It reports whether
+// the file was changed.
+func EnsureInclude(configPath, includePath string) (bool, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	line := "Include " + quote(includePath)
+	for _, l := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(l) == line {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		return false, err
+	}
+	content := line + "\n"
+	if len(data) > 0 {
+		content += "\n" + string(data)
+	}
+	return true, os.WriteFile(configPath, []byte(content), 0o600)
+}
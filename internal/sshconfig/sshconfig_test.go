@@ -0,0 +1,60 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	hosts := []Host{
+		{Name: "web", HostName: "203.0.113.7", User: "ubuntu", IdentityFile: "/home/me/.ssh/id_ed25519"},
+		{Name: "db", HostName: "i-0123456789abcdef0", User: "ubuntu", ProxyCommand: "aws ssm start-session --target %h"},
+		{Name: "old", User: "ubuntu"},
+	}
+
+	want := header + `
+Host privatebox-db
+  HostName i-0123456789abcdef0
+  User ubuntu
+  HostKeyAlias privatebox-db
+  ProxyCommand aws ssm start-session --target %h
+
+# privatebox-old has no address (stopped?)
+
+Host privatebox-web
+  HostName 203.0.113.7
+  User ubuntu
+  HostKeyAlias privatebox-web
+  IdentityFile /home/me/.ssh/id_ed25519
+  IdentitiesOnly yes
+`
+	if got := Render(hosts); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEnsureInclude(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	include := filepath.Join(dir, "privatebox.conf")
+
+	existing := "Host example\n  User me\n"
+	if err := os.WriteFile(configPath, []byte(existing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := EnsureInclude(configPath, include)
+	if err != nil || !changed {
+		t.Fatalf("EnsureInclude() = %v, %v, want true, nil", changed, err)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.HasPrefix(string(data), "Include "+include+"\n") || !strings.HasSuffix(string(data), existing) {
+		t.Errorf("unexpected config:\n%s", data)
+	}
+
+	if changed, err = EnsureInclude(configPath, include); err != nil || changed {
+		t.Errorf("second EnsureInclude() = %v, %v, want false, nil", changed, err)
+	}
+}
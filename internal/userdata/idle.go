@@ -0,0 +1,114 @@
+package userdata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdleStatePath is where the idle watchdog records "<idle-since-epoch> <after-seconds>".
+const IdleStatePath = "/run/privatebox/idle"
+
+// DefaultIdleCPUThreshold is the CPU percentage under which an instance counts as idle.
+const DefaultIdleCPUThreshold = 10
+
+const idleWatchdogScript = `#!/bin/bash
+# Installed by privatebox: stops this instance once it has been idle
+# (no SSH/SSM sessions and CPU below the threshold) for long enough.
+set -euo pipefail
+
+cat > /usr/local/sbin/privatebox-idle-watchdog <<'WATCHDOG'
+#!/bin/bash
+AFTER=__AFTER__
+CPU_THRESHOLD=__CPU__
+STATE=__STATE__
+INTERVAL=60
+
+mkdir -p "$(dirname "$STATE")"
+idle_since=$(date +%s)
+
+cpu_sample() {
+  awk '/^cpu /{idle=$5+$6; total=0; for(i=2;i<=NF;i++) total+=$i; print total-idle, total}' /proc/stat
+}
+
+read -r busy1 total1 < <(cpu_sample)
+while true; do
+  sleep "$INTERVAL"
+  read -r busy2 total2 < <(cpu_sample)
+  delta=$((total2 - total1))
+  [ "$delta" -gt 0 ] || delta=1
+  cpu=$(( (busy2 - busy1) * 100 / delta ))
+  busy1=$busy2
+  total1=$total2
+
+  ssh_sessions=$(ss -Htn state established '( sport = :22 )' | wc -l)
+  ssm_sessions=$(pgrep -c -f ssm-session-worker || true)
+  now=$(date +%s)
+  if [ "$ssh_sessions" -gt 0 ] || [ "${ssm_sessions:-0}" -gt 0 ] || [ "$cpu" -ge "$CPU_THRESHOLD" ]; then
+    idle_since=$now
+  fi
+
+  echo "$idle_since $AFTER" > "$STATE"
+  if [ $((now - idle_since)) -ge "$AFTER" ]; then
+    logger -t privatebox "idle for $((now - idle_since))s, shutting down"
+    shutdown -h now
+  fi
+done
+WATCHDOG
+chmod 0755 /usr/local/sbin/privatebox-idle-watchdog
+
+cat > /etc/systemd/system/privatebox-idle-watchdog.service <<'UNIT'
+[Unit]
+Description=privatebox idle shutdown watchdog
+After=network.target
+
+[Service]
+ExecStart=/usr/local/sbin/privatebox-idle-watchdog
+Restart=always
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+
+systemctl daemon-reload
+systemctl enable --now privatebox-idle-watchdog.service
+`
+
+// IdleWatchdog returns a script that installs a systemd service which shuts the
+// instance down after it has been idle for the given duration. cpuThreshold is
+// a percentage; zero selects DefaultIdleCPUThreshold.
+func IdleWatchdog(after time.Duration, cpuThreshold int) Part {
+	if cpuThreshold <= 0 {
+		cpuThreshold = DefaultIdleCPUThreshold
+	}
+	script := strings.NewReplacer(
+		"__AFTER__", strconv.Itoa(int(after.Seconds())),
+		"__CPU__", strconv.Itoa(cpuThreshold),
+		"__STATE__", IdleStatePath,
+	).Replace(idleWatchdogScript)
+	return Part{Filename: "privatebox-idle-watchdog.sh", Content: script}
+}
+
+// ParseIdleState parses the contents of IdleStatePath and returns how long is
+// left until the watchdog shuts the instance down.
+func ParseIdleState(content string, now time.Time) (time.Duration, error) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("unexpected idle state %q", content)
+	}
+	since, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle timestamp: %w", err)
+	}
+	after, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idle duration: %w", err)
+	}
+
+	remaining := time.Unix(since, 0).Add(time.Duration(after) * time.Second).Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
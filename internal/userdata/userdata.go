@@ -0,0 +1,75 @@
+// Package userdata builds instance user-data (cloud-init) payloads.
+package userdata
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// Part is a single script or cloud-config document.
+type Part struct {
+	Filename string
+	Content  string
+}
+
+// contentType guesses the cloud-init content type from the first line of the part.
+func (p Part) contentType() string {
+	switch {
+	case strings.HasPrefix(p.Content, "#cloud-config"):
+		return "text/cloud-config"
+	case strings.HasPrefix(p.Content, "#include"):
+		return "text/x-include-url"
+	case strings.HasPrefix(p.Content, "#cloud-boothook"):
+		return "text/cloud-boothook"
+	default:
+		return "text/x-shellscript"
+	}
+}
+
+// Compose combines several parts into a single user-data payload.
+// Empty parts are dropped. A single part is returned unchanged so that simple
+// scripts stay readable in the console; multiple parts are wrapped in a
+// MIME multipart archive, which cloud-init runs in order.
+func Compose(parts ...Part) (string, error) {
+	var nonEmpty []Part
+	for _, p := range parts {
+		if strings.TrimSpace(p.Content) != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+
+	switch len(nonEmpty) {
+	case 0:
+		return "", nil
+	case 1:
+		return nonEmpty[0].Content, nil
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range nonEmpty {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("%s; charset=\"us-ascii\"", p.contentType()))
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", p.Filename))
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := pw.Write([]byte(p.Content)); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=%q\r\n", w.Boundary())
+	out.WriteString("MIME-Version: 1.0\r\n\r\n")
+	out.Write(body.Bytes())
+	return out.String(), nil
+}
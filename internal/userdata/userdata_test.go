@@ -0,0 +1,126 @@
+package userdata
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		name      string
+		parts     []Part
+		want      string
+		wantTypes []string
+	}{
+		{
+			name:  "No parts",
+			parts: nil,
+			want:  "",
+		},
+		{
+			name:  "Single part is unchanged",
+			parts: []Part{{Filename: "a", Content: "#!/bin/bash\necho hi\n"}, {Filename: "b", Content: "  "}},
+			want:  "#!/bin/bash\necho hi\n",
+		},
+		{
+			name: "Multiple parts",
+			parts: []Part{
+				{Filename: "config", Content: "#cloud-config\npackages: [git]\n"},
+				{Filename: "script", Content: "#!/bin/bash\necho hi\n"},
+			},
+			wantTypes: []string{"text/cloud-config", "text/x-shellscript"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compose(tt.parts...)
+			if err != nil {
+				t.Fatalf("Compose() error = %v", err)
+			}
+
+			if tt.wantTypes == nil {
+				if got != tt.want {
+					t.Errorf("Compose() = %q, want %q", got, tt.want)
+				}
+				return
+			}
+
+			header, body, ok := strings.Cut(got, "\r\n\r\n")
+			if !ok {
+				t.Fatalf("Compose() output has no header: %q", got)
+			}
+			_, params, err := mime.ParseMediaType(strings.TrimPrefix(strings.Split(header, "\r\n")[0], "Content-Type: "))
+			if err != nil {
+				t.Fatalf("invalid content type: %v", err)
+			}
+
+			r := multipart.NewReader(strings.NewReader(body), params["boundary"])
+			for i, wantType := range tt.wantTypes {
+				part, err := r.NextPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				if ct := part.Header.Get("Content-Type"); !strings.HasPrefix(ct, wantType) {
+					t.Errorf("part %d content type = %s, want %s", i, ct, wantType)
+				}
+				content, _ := io.ReadAll(part)
+				if string(content) != tt.parts[i].Content {
+					t.Errorf("part %d content = %q, want %q", i, content, tt.parts[i].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleWatchdog(t *testing.T) {
+	part := IdleWatchdog(45*time.Minute, 0)
+	for _, want := range []string{"AFTER=2700", "CPU_THRESHOLD=10", "STATE=" + IdleStatePath} {
+		if !strings.Contains(part.Content, want) {
+			t.Errorf("watchdog script missing %q", want)
+		}
+	}
+}
+
+func TestParseIdleState(t *testing.T) {
+	now := time.Unix(10000, 0)
+	tests := []struct {
+		name    string
+		content string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "Remaining", content: "9400 2700\n", want: 35 * time.Minute},
+		{name: "Overdue", content: "1000 60", want: 0},
+		{name: "Malformed", content: "garbage", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIdleState(tt.content, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIdleState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIdleState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMountVolumes(t *testing.T) {
+	if part := MountVolumes(nil); part.Content != "" {
+		t.Errorf("MountVolumes(nil) = %q, want empty", part.Content)
+	}
+
+	part := MountVolumes([]Mount{{Device: "/dev/sdf", MountPoint: "/data"}, {Device: "/dev/sdg", MountPoint: "/srv/it's"}})
+	for _, want := range []string{"setup '/dev/sdf' '/data' || status=1", `setup '/dev/sdg' '/srv/it'\''s' || status=1`} {
+		if !strings.Contains(part.Content, want) {
+			t.Errorf("volumes script missing %q", want)
+		}
+	}
+}
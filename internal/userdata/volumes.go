@@ -0,0 +1,132 @@
+package userdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VolumesScriptPath is where the volume setup script is installed on the instance.
+const VolumesScriptPath = "/usr/local/sbin/privatebox-volumes"
+
+// GrowFilesystemsCommand grows the root file system and the data volumes'
+// file systems to the size of their (resized) volumes. It is run over SSH.
+const GrowFilesystemsCommand = `set -e
+root=$(findmnt -no SOURCE /)
+disk=/dev/$(lsblk -no PKNAME "$root")
+part=$(cat "/sys/class/block/$(basename "$root")/partition" 2>/dev/null || true)
+if [ -n "$part" ]; then growpart "$disk" "$part" || true; fi
+case $(findmnt -no FSTYPE /) in
+  xfs) xfs_growfs / ;;
+  *) resize2fs "$root" ;;
+esac
+if [ -x ` + VolumesScriptPath + ` ]; then ` + VolumesScriptPath + `; fi`
+
+// Mount is a block device that is formatted and mounted on the instance.
+type Mount struct {
+	Device     string // Device name the volume is attached as, e.g. "/dev/sdf"
+	MountPoint string
+}
+
+const volumesScript = `#!/bin/bash
+# Installed by privatebox: formats, mounts and grows the instance's data volumes.
+set -euo pipefail
+
+cat > ` + VolumesScriptPath + ` <<'VOLUMES'
+#!/bin/bash
+set -u
+TIMEOUT=600
+
+# resolve prints the block device for an attachment name like /dev/sdf. On Nitro
+# instances volumes show up as NVMe devices that report the name in their
+# vendor specific controller data.
+resolve() {
+  local name=${1#/dev/}
+  for dev in "/dev/$name" "/dev/xvd${name#sd}"; do
+    if [ -b "$dev" ]; then readlink -f "$dev"; return 0; fi
+  done
+  for dev in /dev/nvme*n1; do
+    [ -b "$dev" ] || continue
+    local vendor
+    vendor=$(nvme id-ctrl --raw-binary "$dev" 2>/dev/null | cut -c3073-3104 | tr -d ' \0')
+    if [ "${vendor#/dev/}" = "$name" ]; then echo "$dev"; return 0; fi
+  done
+  return 1
+}
+
+setup() {
+  local name=$1 mountpoint=$2 dev="" waited=0
+  # Volumes are attached after the instance has booted
+  until dev=$(resolve "$name"); do
+    if [ "$waited" -ge "$TIMEOUT" ]; then
+      logger -t privatebox "volume $name for $mountpoint not attached"
+      return 1
+    fi
+    sleep 5
+    waited=$((waited + 5))
+  done
+
+  mkdir -p "$mountpoint"
+  if ! blkid "$dev" >/dev/null 2>&1; then
+    mkfs.ext4 -q "$dev"
+    # Seed a new volume with what is already there (e.g. /home/ubuntu/.ssh)
+    if [ -n "$(ls -A "$mountpoint")" ]; then
+      local seed
+      seed=$(mktemp -d)
+      mount "$dev" "$seed" && cp -a "$mountpoint/." "$seed/" && umount "$seed"
+      rmdir "$seed"
+    fi
+  fi
+  local uuid
+  uuid=$(blkid -s UUID -o value "$dev")
+  if ! grep -q "UUID=$uuid " /etc/fstab; then
+    echo "UUID=$uuid $mountpoint ext4 defaults,nofail 0 2" >> /etc/fstab
+  fi
+  mountpoint -q "$mountpoint" || mount "$mountpoint"
+  # Grow the file system after the volume has been resized (no-op otherwise)
+  resize2fs "$dev" >/dev/null 2>&1 || true
+}
+
+status=0
+__SETUP__
+exit $status
+VOLUMES
+chmod 0755 ` + VolumesScriptPath + `
+
+cat > /etc/systemd/system/privatebox-volumes.service <<'UNIT'
+[Unit]
+Description=privatebox data volumes
+After=local-fs.target
+
+[Service]
+Type=oneshot
+ExecStart=` + VolumesScriptPath + `
+RemainAfterExit=yes
+
+[Install]
+WantedBy=multi-user.target
+UNIT
+
+systemctl daemon-reload
+systemctl enable privatebox-volumes.service
+systemctl start --no-block privatebox-volumes.service
+`
+
+// MountVolumes returns a script that installs a boot-time service which formats
+// (if blank), mounts and grows the given volumes.
+func MountVolumes(mounts []Mount) Part {
+	if len(mounts) == 0 {
+		return Part{Filename: "privatebox-volumes.sh"}
+	}
+
+	setup := make([]string, 0, len(mounts))
+	for _, m := range mounts {
+		setup = append(setup, fmt.Sprintf("setup %s %s || status=1", ShellQuote(m.Device), ShellQuote(m.MountPoint)))
+	}
+	script := strings.Replace(volumesScript, "__SETUP__", strings.Join(setup, "\n"), 1)
+	return Part{Filename: "privatebox-volumes.sh", Content: script}
+}
+
+// ShellQuote quotes s for use as a single shell word.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}